@@ -0,0 +1,137 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math/rand"
+)
+
+// Attention computes a context vector from the rows of a mixer matrix
+type Attention interface {
+	Attend(input Matrix[float32]) [InputSize]float32
+}
+
+// MultiHeadAttention is multi head scaled dot product attention with per head
+// query, key and value projections and an output projection
+type MultiHeadAttention struct {
+	Heads int
+	Size  int
+	Q     []Matrix[float32]
+	K     []Matrix[float32]
+	V     []Matrix[float32]
+	O     Matrix[float32]
+}
+
+// NewMultiHeadAttention makes a new multi head attention with heads of size
+// and weights drawn from rng
+func NewMultiHeadAttention(rng *rand.Rand, heads, size int) *MultiHeadAttention {
+	random := func(cols, rows int) Matrix[float32] {
+		m := NewMatrix[float32](cols, rows)
+		scale := 1 / sqrt(float32(cols))
+		for i := 0; i < cols*rows; i++ {
+			m.Data = append(m.Data, float32(rng.NormFloat64())*scale)
+		}
+		return m
+	}
+	a := &MultiHeadAttention{
+		Heads: heads,
+		Size:  size,
+	}
+	for i := 0; i < heads; i++ {
+		a.Q = append(a.Q, random(InputSize, size))
+		a.K = append(a.K, random(InputSize, size))
+		a.V = append(a.V, random(InputSize, size))
+	}
+	a.O = random(heads*size, InputSize)
+	return a
+}
+
+// Forward computes the attention of the input rows and projects them back to the input size
+func (a *MultiHeadAttention) Forward(input Matrix[float32]) Matrix[float32] {
+	heads := NewMatrix[float32](a.Heads*a.Size, input.Rows)
+	outputs := make([]Matrix[float32], a.Heads)
+	scale := 1 / sqrt(float32(a.Size))
+	for h := 0; h < a.Heads; h++ {
+		Q, K, V := a.Q[h].MulT(input), a.K[h].MulT(input), a.V[h].MulT(input)
+		scores := K.MulT(Q)
+		for i := range scores.Data {
+			scores.Data[i] *= scale
+		}
+		outputs[h] = V.T().MulT(scores.Softmax(1))
+	}
+	for i := 0; i < input.Rows; i++ {
+		for h := 0; h < a.Heads; h++ {
+			heads.Data = append(heads.Data, outputs[h].Data[i*a.Size:(i+1)*a.Size]...)
+		}
+	}
+	return a.O.MulT(heads)
+}
+
+// Attend computes the attention of the input rows, sums the output rows and normalizes the result
+func (a *MultiHeadAttention) Attend(input Matrix[float32]) [InputSize]float32 {
+	projected := a.Forward(input)
+	output := [InputSize]float32{}
+	for i := 0; i < projected.Rows; i++ {
+		for j, v := range projected.Data[i*projected.Cols : (i+1)*projected.Cols] {
+			output[j] += v
+		}
+	}
+	return Normalize(output)
+}
+
+// MarshalBinary encodes the attention weights
+func (a *MultiHeadAttention) MarshalBinary() ([]byte, error) {
+	buffer := bytes.Buffer{}
+	err := binary.Write(&buffer, binary.LittleEndian, [2]uint32{uint32(a.Heads), uint32(a.Size)})
+	if err != nil {
+		return nil, err
+	}
+	for h := 0; h < a.Heads; h++ {
+		for _, m := range []Matrix[float32]{a.Q[h], a.K[h], a.V[h]} {
+			err := binary.Write(&buffer, binary.LittleEndian, m.Data)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+	err = binary.Write(&buffer, binary.LittleEndian, a.O.Data)
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// UnmarshalBinary decodes the attention weights
+func (a *MultiHeadAttention) UnmarshalBinary(data []byte) error {
+	reader := bytes.NewReader(data)
+	var header [2]uint32
+	err := binary.Read(reader, binary.LittleEndian, &header)
+	if err != nil {
+		return err
+	}
+	heads, size := int(header[0]), int(header[1])
+	if expected := 8 + 4*(3*heads*size*InputSize+heads*size*InputSize); len(data) != expected {
+		return fmt.Errorf("multi head attention is %d bytes, expected %d", len(data), expected)
+	}
+	read := func(cols, rows int) (Matrix[float32], error) {
+		m := NewMatrix(cols, rows, make([]float32, cols*rows)...)
+		return m, binary.Read(reader, binary.LittleEndian, m.Data)
+	}
+	a.Heads, a.Size = heads, size
+	a.Q, a.K, a.V = make([]Matrix[float32], heads), make([]Matrix[float32], heads), make([]Matrix[float32], heads)
+	for h := 0; h < heads; h++ {
+		for _, m := range []*Matrix[float32]{&a.Q[h], &a.K[h], &a.V[h]} {
+			*m, err = read(InputSize, size)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	a.O, err = read(heads*size, InputSize)
+	return err
+}
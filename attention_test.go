@@ -0,0 +1,51 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestMultiHeadAttention(t *testing.T) {
+	a := NewMultiHeadAttention(rand.New(rand.NewSource(1)), 4, 16)
+	b := NewMultiHeadAttention(rand.New(rand.NewSource(1)), 4, 16)
+	f := NewFiltered()
+	f.Attention = a
+	for _, v := range []byte("hello world") {
+		f.Add(v)
+	}
+	x := f.Mix()
+	g := f.Copy().(*Filtered)
+	g.Attention = b
+	if y := g.Mix(); x != y {
+		t.Fatal("attention with the same seed differs")
+	}
+	if norm := NCS(x[:], x[:]); math.Abs(float64(norm)-1) > 1e-5 {
+		t.Fatalf("%f != 1", norm)
+	}
+	f.Attention = nil
+	if y := f.Mix(); x == y {
+		t.Fatal("multi head attention is the identity attention")
+	}
+
+	data, err := a.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &MultiHeadAttention{}
+	err = c.UnmarshalBinary(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Attention = c
+	if y := g.Mix(); x != y {
+		t.Fatal("unmarshaled attention differs")
+	}
+	if err := c.UnmarshalBinary(data[:len(data)-4]); err == nil {
+		t.Fatal("expected an error for truncated data")
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Sequence is a generated sequence scored by its summed log probability
+type Sequence struct {
+	Text  []byte
+	Score float64
+	Model *Model
+}
+
+// Penalized is the score normalized by the GNMT length penalty ((5+|Y|)/6)^penalty
+func (s Sequence) Penalized(penalty float64) float64 {
+	return s.Score / math.Pow((5+float64(len(s.Text)))/6, penalty)
+}
+
+// Sort sorts sequences from best to worst penalized score
+func Sort(sequences []Sequence, penalty float64) {
+	sort.SliceStable(sequences, func(i, j int) bool {
+		return sequences[i].Penalized(penalty) > sequences[j].Penalized(penalty)
+	})
+}
+
+// Top returns the n most probable symbols
+func (d *Distribution) Top(n int) []byte {
+	symbols := make([]byte, 256)
+	for i := range symbols {
+		symbols[i] = byte(i)
+	}
+	sort.SliceStable(symbols, func(i, j int) bool {
+		return d[symbols[i]] > d[symbols[j]]
+	})
+	if n > len(symbols) {
+		n = len(symbols)
+	}
+	return symbols[:n]
+}
+
+// Beam generates up to length symbols with beam search, branching the model with Copy
+// Sequences end early at the stop symbol unless stop is negative
+func Beam(m *Model, width, length int, penalty float64, stop int) []Sequence {
+	type Candidate struct {
+		Parent int
+		Symbol byte
+		Score  float64
+	}
+	beams, finished := []Sequence{{Model: m.Copy()}}, []Sequence{}
+	for i := 0; i < length && len(beams) > 0; i++ {
+		candidates := []Candidate{}
+		for j := range beams {
+			d := beams[j].Model.Predict()
+			for _, s := range d.Top(width) {
+				candidates = append(candidates, Candidate{
+					Parent: j,
+					Symbol: s,
+					Score:  beams[j].Score + math.Log(d[s]),
+				})
+			}
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return candidates[i].Score > candidates[j].Score
+		})
+		if len(candidates) > width {
+			candidates = candidates[:width]
+		}
+		next := make([]Sequence, 0, width)
+		for _, candidate := range candidates {
+			parent := beams[candidate.Parent]
+			text := make([]byte, len(parent.Text), len(parent.Text)+1)
+			copy(text, parent.Text)
+			sequence := Sequence{
+				Text:  append(text, candidate.Symbol),
+				Score: candidate.Score,
+				Model: parent.Model.Copy(),
+			}
+			sequence.Model.Add(candidate.Symbol)
+			if int(candidate.Symbol) == stop {
+				finished = append(finished, sequence)
+				continue
+			}
+			next = append(next, sequence)
+		}
+		beams = next
+	}
+	sequences := append(finished, beams...)
+	Sort(sequences, penalty)
+	if len(sequences) > width {
+		sequences = sequences[:width]
+	}
+	return sequences
+}
+
+// BestOfN samples n sequences of up to length symbols and returns them from best to worst
+// Sequences end early at the stop symbol unless stop is negative
+func BestOfN(rng *rand.Rand, m *Model, n, length int, penalty float64, stop int) []Sequence {
+	sequences := make([]Sequence, n)
+	for i := range sequences {
+		sequence := Sequence{
+			Model: m.Copy(),
+		}
+		for j := 0; j < length; j++ {
+			d := sequence.Model.Predict()
+			s := d.Sample(rng)
+			sequence.Text = append(sequence.Text, s)
+			sequence.Score += math.Log(d[s])
+			sequence.Model.Add(s)
+			if int(s) == stop {
+				break
+			}
+		}
+		sequences[i] = sequence
+	}
+	Sort(sequences, penalty)
+	return sequences
+}
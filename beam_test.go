@@ -0,0 +1,50 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+)
+
+func TestBeam(t *testing.T) {
+	transforms := GetTransforms()
+	m := NewModel(&transforms, nil, NewSuffix([]byte("the cat sat on the mat.")), false)
+	for _, v := range []byte("the c") {
+		m.Add(v)
+	}
+	context := string(m.Context)
+	sequences := Beam(m, 4, 8, 0, '.')
+	if len(sequences) != 4 {
+		t.Fatalf("%d != 4", len(sequences))
+	}
+	if !bytes.HasPrefix(sequences[0].Text, []byte("at sat")) {
+		t.Fatalf("%q", sequences[0].Text)
+	}
+	for i := 1; i < len(sequences); i++ {
+		if sequences[i].Score > sequences[i-1].Score {
+			t.Fatalf("%f > %f", sequences[i].Score, sequences[i-1].Score)
+		}
+	}
+	if string(m.Context) != context {
+		t.Fatal("beam search modified the model")
+	}
+}
+
+func TestBestOfN(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	transforms := GetTransforms()
+	m := NewModel(&transforms, nil, NewSuffix([]byte("the cat sat on the mat.")), true)
+	sequences := BestOfN(rng, m, 8, 16, 1, '.')
+	if len(sequences) != 8 {
+		t.Fatalf("%d != 8", len(sequences))
+	}
+	for i := 1; i < len(sequences); i++ {
+		if sequences[i].Penalized(1) > sequences[i-1].Penalized(1) {
+			t.Fatalf("%f > %f", sequences[i].Penalized(1), sequences[i-1].Penalized(1))
+		}
+	}
+}
@@ -0,0 +1,214 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	// ByteBits is the number of binary decisions per byte, from the most significant bit
+	// down, the context of a decision is the mixer vector and the node, which is the bits
+	// of the byte so far after a leading one, so the nodes of a byte go from 1 to 255
+	ByteBits = 8
+	// BitStride separates the keys of the nodes, it is a multiple of 2*Backoff so adding
+	// it keeps neighboring keys together for the search window
+	BitStride uint32 = 0x9e377 << 11
+	// BinaryContexts is the number of slot table keys that are contexts of a Binary
+	BinaryContexts = 4
+	// BinaryShift is the number of low key bits dropped to quantize a Binary context
+	BinaryShift = 12
+	// BinaryBits is the number of index bits of the Binary evaluated from the command line
+	BinaryBits = 22
+	// BinaryRate is the rate of the Binary evaluated from the command line
+	BinaryRate = 4
+)
+
+// BitKeys offsets the slot table keys of a mixer vector for a node
+func BitKeys(keys *[Transforms]uint32, node int) (bits [Transforms]uint32) {
+	offset := uint32(node) * BitStride
+	for i, key := range keys {
+		bits[i] = key + offset
+	}
+	return bits
+}
+
+// SetBits stores the bits of the next symbol for the keys, a zero bit is stored as 1 and a
+// one bit as 2 because 0 is an empty slot
+func (s *Symbols) SetBits(keys *[Transforms]uint32, symbol byte) {
+	node := 1
+	for i := ByteBits - 1; i >= 0; i-- {
+		bit := int(symbol>>i) & 1
+		bits := BitKeys(keys, node)
+		s.Set(&bits, byte(1+bit))
+		node = 2*node + bit
+	}
+}
+
+// SearchBit searches the bit table for the probability of a one bit at node
+func (s *SymbolTable) SearchBit(keys *[Transforms]uint32, node int) float64 {
+	bits := BitKeys(keys, node)
+	histogram := s.Search(&bits)
+	// the Krichevsky–Trofimov estimate is a half without any counts
+	return (float64(histogram[2]) + .5) / (float64(histogram[1]+histogram[2]) + 1)
+}
+
+// BitCost is the number of bits needed to code s with the bit table, it searches only the
+// ByteBits nodes on the path of s
+func (s *SymbolTable) BitCost(keys *[Transforms]uint32, symbol byte) float64 {
+	cost, node := 0.0, 1
+	for i := ByteBits - 1; i >= 0; i-- {
+		bit := int(symbol>>i) & 1
+		p := s.SearchBit(keys, node)
+		if bit == 0 {
+			p = 1 - p
+		}
+		cost -= math.Log2(p)
+		node = 2*node + bit
+	}
+	return cost
+}
+
+// SearchBits computes the distribution of the next symbol from the bit table, the 255 nodes
+// are all searched
+func (s *SymbolTable) SearchBits(keys *[Transforms]uint32) (d Distribution) {
+	var paths [512]float64
+	paths[1] = 1
+	for node := 1; node < 256; node++ {
+		p := s.SearchBit(keys, node)
+		paths[2*node] = paths[node] * (1 - p)
+		paths[2*node+1] = paths[node] * p
+	}
+	copy(d[:], paths[256:])
+	return d
+}
+
+// TrainBits trains the bit table on data with the keys from m
+func TrainBits(m Mix, data []byte, symbols *Symbols, verbose bool) {
+	m.Add(0)
+	transforms := GetTransforms()
+	for j, v := range data {
+		vv := m.Mix()
+		keys := Keys(&transforms, &vv)
+		symbols.SetBits(&keys, v)
+		m.Add(v)
+		if verbose {
+			fmt.Println(float64(j) / float64(len(data)))
+		}
+	}
+}
+
+// EvaluateBits computes the bits per byte of data with the bit table and the keys from m
+func EvaluateBits(m Mix, table *SymbolTable, data []byte) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+	m.Add(0)
+	transforms := GetTransforms()
+	bits := 0.0
+	for _, v := range data {
+		vv := m.Mix()
+		keys := Keys(&transforms, &vv)
+		bits += table.BitCost(&keys, v)
+		m.Add(v)
+	}
+	return bits / float64(len(data))
+}
+
+// Binary is an adaptive binary cdf for every node in every context, the contexts are the
+// first BinaryContexts slot table keys quantized by BinaryShift and hashed with the node
+// into a table of 2^Bits probabilities, the cdf of a decision is the average over the
+// contexts
+type Binary struct {
+	Bits   uint
+	Rate   int
+	Zeros  []uint16
+	Index  [BinaryContexts]uint32
+	Model  []uint16
+	Verify bool
+}
+
+// NewBinary makes a new binary model with 2^bits probabilities adapting at rate
+func NewBinary(bits uint, rate int, verify bool) *Binary {
+	if bits < 1 || bits > 32 {
+		panic(fmt.Errorf("binary table bits %d are out of range", bits))
+	}
+	if rate < 1 || rate > CDF16Fixed {
+		panic(fmt.Errorf("binary rate %d is out of range", rate))
+	}
+	zeros := make([]uint16, 1<<bits)
+	for i := range zeros {
+		zeros[i] = CDF16Scale / 2
+	}
+	return &Binary{
+		Bits:   bits,
+		Rate:   rate,
+		Zeros:  zeros,
+		Model:  []uint16{0, CDF16Scale / 2, CDF16Scale},
+		Verify: verify,
+	}
+}
+
+// Context selects the probabilities of node for the keys and returns the cdf of the decision,
+// the cdf is valid until the next call
+func (b *Binary) Context(keys *[Transforms]uint32, node int) []uint16 {
+	sum := 0
+	for i := range b.Index {
+		hash := (keys[i]>>BinaryShift + uint32(node)*BitStride) * 0x9e3779b1
+		b.Index[i] = hash >> (32 - b.Bits)
+		sum += int(b.Zeros[b.Index[i]])
+	}
+	// each symbol keeps a count of at least one
+	zero := min(max(sum/len(b.Index), 1), CDF16Scale-1)
+	b.Model[1] = uint16(zero)
+	if b.Verify {
+		verify16(b.Model)
+	}
+	return b.Model
+}
+
+// Update moves the probabilities of the last context towards bit
+func (b *Binary) Update(bit int) {
+	target := 0
+	if bit == 0 {
+		target = CDF16Scale
+	}
+	for _, index := range b.Index {
+		a := int(b.Zeros[index])
+		b.Zeros[index] = uint16(a + ((target - a) >> b.Rate))
+	}
+}
+
+// Cost is the number of bits needed to code s, the model learns s as it is coded
+func (b *Binary) Cost(keys *[Transforms]uint32, symbol byte) float64 {
+	cost, node := 0.0, 1
+	for i := ByteBits - 1; i >= 0; i-- {
+		bit := int(symbol>>i) & 1
+		model := b.Context(keys, node)
+		cost -= math.Log2(float64(model[bit+1]-model[bit]) / CDF16Scale)
+		b.Update(bit)
+		node = 2*node + bit
+	}
+	return cost
+}
+
+// EvaluateBinary computes the bits per byte of adaptively coding data with the binary model
+// and the keys from m
+func EvaluateBinary(m Mix, b *Binary, data []byte) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+	m.Add(0)
+	transforms := GetTransforms()
+	bits := 0.0
+	for _, v := range data {
+		vv := m.Mix()
+		keys := Keys(&transforms, &vv)
+		bits += b.Cost(&keys, v)
+		m.Add(v)
+	}
+	return bits / float64(len(data))
+}
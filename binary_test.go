@@ -0,0 +1,79 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestBitKeys(t *testing.T) {
+	var keys [Transforms]uint32
+	for i := range keys {
+		keys[i] = uint32(i * 3)
+	}
+	a, b := BitKeys(&keys, 1), BitKeys(&keys, 2)
+	for i := range keys {
+		// neighboring keys stay neighbors and the nodes are far apart
+		if a[i]-a[0] != keys[i]-keys[0] {
+			t.Fatalf("key %d moved relative to key 0", i)
+		}
+		if d := int64(b[i]&(1<<20-1)) - int64(a[i]&(1<<20-1)); d > -2*Backoff && d < 2*Backoff {
+			t.Fatalf("nodes 1 and 2 are %d slots apart", d)
+		}
+	}
+}
+
+func TestBits(t *testing.T) {
+	data := []byte(strings.Repeat("the quick brown fox jumps over the lazy dog. ", 8))
+	// each byte sets ByteBits times as many slots as a symbol table
+	symbols := NewSymbols(22)
+	TrainBits(NewFiltered(), data, symbols, false)
+	table := symbols.Table()
+	if bits := EvaluateBits(NewFiltered(), table, data); bits > 4 {
+		t.Fatalf("%f bits per byte on the training data", bits)
+	}
+
+	m := NewFiltered()
+	m.Add(0)
+	transforms := GetTransforms()
+	vv := m.Mix()
+	keys := Keys(&transforms, &vv)
+	d := table.SearchBits(&keys)
+	sum := 0.0
+	for _, v := range d {
+		sum += v
+	}
+	if math.Abs(sum-1) > 1e-9 {
+		t.Fatalf("distribution sums to %f", sum)
+	}
+	if cost := table.BitCost(&keys, 't'); math.Abs(cost+math.Log2(d['t'])) > 1e-9 {
+		t.Fatalf("cost %f does not match the distribution %f", cost, -math.Log2(d['t']))
+	}
+	if top := d.Top(1)[0]; top != 't' {
+		t.Fatalf("%q is the most likely first symbol", top)
+	}
+
+	// the model searches a bit table node by node
+	table.Bits = true
+	model := NewModel(&transforms, table, nil, false)
+	if p := model.Predict(); math.Abs(p['t']-d['t']) > 1e-3 {
+		t.Fatalf("model predicts %f for the first symbol, not %f", p['t'], d['t'])
+	}
+}
+
+func TestBinary(t *testing.T) {
+	data := []byte(strings.Repeat("the quick brown fox jumps over the lazy dog. ", 32))
+	b := NewBinary(18, 4, true)
+	first := EvaluateBinary(NewFiltered(), b, data[:len(data)/4])
+	second := EvaluateBinary(NewFiltered(), b, data[:len(data)/4])
+	if second >= first {
+		t.Fatalf("%f bits per byte did not go down from %f", second, first)
+	}
+	if first >= 8 {
+		t.Fatalf("%f bits per byte", first)
+	}
+}
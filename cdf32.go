@@ -0,0 +1,102 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+)
+
+const (
+	// CDF32Fixed is the shift for 32 bit coders
+	CDF32Fixed = 32 - 4
+	// CDF32Scale is the scale for 32 bit coder
+	CDF32Scale = 1 << CDF32Fixed
+)
+
+// CDF32 is an adaptive cdf with 32 bit entries and a scale of CDF32Scale, for alphabets
+// where the CDF16Scale is too coarse
+type CDF32 struct {
+	Size   int
+	Rate   int
+	Model  []uint32
+	Verify bool
+}
+
+// Filtered32 is an adaptive cdf with 32 bit entries
+type Filtered32 interface {
+	GetModel() []uint32
+	Copy() Filtered32
+	Update(s uint32)
+}
+
+// CDF32Maker makes a Filtered32 for an alphabet of size symbols adapting at rate
+type CDF32Maker func(size, rate int) Filtered32
+
+// NewCDF32 makes CDF32s, a CDF32 starts uniform and each update moves it towards the cdf
+// that gives every symbol a count of one and the updated symbol the rest of the scale
+func NewCDF32(verify bool) CDF32Maker {
+	return func(size, rate int) Filtered32 {
+		if err := Headroom(size, CDF32Scale); err != nil {
+			panic(err)
+		}
+		model := make([]uint32, size+1)
+		for i := range model {
+			model[i] = uint32(int64(i) * CDF32Scale / int64(size))
+		}
+
+		return &CDF32{
+			Size:   size,
+			Rate:   rate,
+			Model:  model,
+			Verify: verify,
+		}
+	}
+}
+
+// Copy copies the model
+func (c *CDF32) Copy() Filtered32 {
+	model := make([]uint32, len(c.Model))
+	copy(model, c.Model)
+	return &CDF32{
+		Size:   c.Size,
+		Rate:   c.Rate,
+		Model:  model,
+		Verify: c.Verify,
+	}
+}
+
+// GetModel gets the cdf
+func (c *CDF32) GetModel() []uint32 {
+	return c.Model
+}
+
+// Update the cdf
+func (c *CDF32) Update(s uint32) {
+	model, size, rate := c.Model, len(c.Model)-1, c.Rate
+	if int64(s) >= int64(size) {
+		panic(fmt.Sprintf("symbol %d is not less than %d", s, size))
+	}
+	// the target cdf has entry i for i <= s and entry i + offset after s
+	offset := int64(CDF32Scale - size)
+	for i := 1; i <= int(s); i++ {
+		a := int64(model[i])
+		model[i] = uint32(a + ((int64(i) - a) >> rate))
+	}
+	for i := int(s) + 1; i < size; i++ {
+		a := int64(model[i])
+		model[i] = uint32(a + ((int64(i) + offset - a) >> rate))
+	}
+
+	if c.Verify {
+		if model[size] != CDF32Scale {
+			panic("cdf scale is incorrect")
+		}
+		for i := 1; i < len(model); i++ {
+			if a, b := model[i], model[i-1]; a <= b {
+				panic(fmt.Sprintf("invalid cdf %v,%v <= %v,%v", i, a, i-1, b))
+			}
+		}
+	}
+}
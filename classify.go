@@ -0,0 +1,258 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+)
+
+const (
+	// ClassBits is the number of index bits in each class symbol table
+	ClassBits = 22
+)
+
+// Document is a labeled document
+type Document struct {
+	Label string
+	Name  string
+	Data  []byte
+}
+
+// ReadDocuments reads the documents in each labeled subdirectory of root
+func ReadDocuments(root string) ([]Document, error) {
+	labels, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	documents := []Document{}
+	for _, label := range labels {
+		if !label.IsDir() {
+			continue
+		}
+		err := filepath.WalkDir(filepath.Join(root, label.Name()), func(path string, d os.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			documents = append(documents, Document{
+				Label: label.Name(),
+				Name:  path,
+				Data:  data,
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return documents, nil
+}
+
+// Split splits each label's documents into training and held out documents
+func Split(rng *rand.Rand, documents []Document, holdout float64) (train, test []Document) {
+	labels := make(map[string][]Document)
+	for _, document := range documents {
+		labels[document.Label] = append(labels[document.Label], document)
+	}
+	names := make([]string, 0, len(labels))
+	for label := range labels {
+		names = append(names, label)
+	}
+	sort.Strings(names)
+	for _, label := range names {
+		documents := labels[label]
+		rng.Shuffle(len(documents), func(i, j int) {
+			documents[i], documents[j] = documents[j], documents[i]
+		})
+		n := int(holdout * float64(len(documents)))
+		if n == 0 && len(documents) > 1 && holdout > 0 {
+			n = 1
+		}
+		test = append(test, documents[:n]...)
+		train = append(train, documents[n:]...)
+	}
+	return train, test
+}
+
+// ErrNoLabels is returned by a classifier trained without documents
+var ErrNoLabels = errors.New("the classifier has no labels")
+
+// Classifier labels documents by the class model with the lowest bits per byte
+type Classifier struct {
+	Labels []string
+	Models []*Model
+}
+
+// NewClassifier trains a model with an in memory table and suffix array for each label
+func NewClassifier(documents []Document, bits uint) *Classifier {
+	corpora := make(map[string][]byte)
+	for _, document := range documents {
+		corpora[document.Label] = append(append(corpora[document.Label], document.Data...), 0)
+	}
+	labels := make([]string, 0, len(corpora))
+	for label := range corpora {
+		labels = append(labels, label)
+	}
+	sort.Strings(labels)
+	transforms := GetTransforms()
+	models := make([]*Model, len(labels))
+	for i, label := range labels {
+		symbols := NewSymbols(bits)
+		Train(NewFiltered(), corpora[label], symbols, nil, false)
+		models[i] = NewModel(&transforms, symbols.Table(), NewSuffix(corpora[label]), false)
+	}
+	return &Classifier{
+		Labels: labels,
+		Models: models,
+	}
+}
+
+// Classify returns the label with the lowest bits per byte and the bits per byte of each label,
+// a classifier without labels returns an error
+func (c *Classifier) Classify(document []byte) (string, []float64, error) {
+	if len(c.Models) == 0 {
+		return "", nil, ErrNoLabels
+	}
+	costs, best := make([]float64, len(c.Models)), 0
+	for i, model := range c.Models {
+		costs[i] = Score(model, document).BitsPerByte
+		if costs[i] < costs[best] {
+			best = i
+		}
+	}
+	return c.Labels[best], costs, nil
+}
+
+// Confusion is a confusion matrix, rows are the true labels and columns the predicted labels,
+// documents with labels the classifier was not trained on are skipped
+type Confusion struct {
+	Labels  []string
+	Counts  [][]int
+	Skipped int
+}
+
+// Evaluate classifies the held out documents and builds the confusion matrix
+func (c *Classifier) Evaluate(documents []Document) (Confusion, error) {
+	if len(c.Models) == 0 {
+		return Confusion{}, ErrNoLabels
+	}
+	index := make(map[string]int)
+	for i, label := range c.Labels {
+		index[label] = i
+	}
+	confusion := Confusion{
+		Labels: c.Labels,
+		Counts: make([][]int, len(c.Labels)),
+	}
+	for i := range confusion.Counts {
+		confusion.Counts[i] = make([]int, len(c.Labels))
+	}
+	for _, document := range documents {
+		actual, ok := index[document.Label]
+		if !ok {
+			confusion.Skipped++
+			continue
+		}
+		label, _, err := c.Classify(document.Data)
+		if err != nil {
+			return confusion, err
+		}
+		confusion.Counts[actual][index[label]]++
+	}
+	return confusion, nil
+}
+
+// Accuracy is the fraction of documents on the diagonal
+func (c Confusion) Accuracy() float64 {
+	correct, total := 0, 0
+	for i := range c.Counts {
+		for j, v := range c.Counts[i] {
+			if i == j {
+				correct += v
+			}
+			total += v
+		}
+	}
+	if total == 0 {
+		return 0
+	}
+	return float64(correct) / float64(total)
+}
+
+// Write writes the confusion matrix
+func (c Confusion) Write(w io.Writer) error {
+	writer := tabwriter.NewWriter(w, 0, 8, 1, ' ', tabwriter.AlignRight)
+	header := bytes.Buffer{}
+	header.WriteString("actual\\predicted\t")
+	for _, label := range c.Labels {
+		header.WriteString(label + "\t")
+	}
+	fmt.Fprintln(writer, header.String())
+	for i, label := range c.Labels {
+		fmt.Fprintf(writer, "%s\t", label)
+		for _, v := range c.Counts[i] {
+			fmt.Fprintf(writer, "%d\t", v)
+		}
+		fmt.Fprintln(writer)
+	}
+	err := writer.Flush()
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "accuracy %f\n", c.Accuracy())
+	if err != nil || c.Skipped == 0 {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "skipped %d documents with untrained labels\n", c.Skipped)
+	return err
+}
+
+// Classify trains a classifier on the labeled directories in root, reports the held out
+// confusion matrix and labels the given files
+func Classify(root string, holdout float64, files []string) {
+	rng := rand.New(rand.NewSource(1))
+	documents, err := ReadDocuments(root)
+	if err != nil {
+		panic(err)
+	}
+	train, test := Split(rng, documents, holdout)
+	classifier := NewClassifier(train, ClassBits)
+	if len(test) > 0 {
+		confusion, err := classifier.Evaluate(test)
+		if err != nil {
+			panic(err)
+		}
+		err = confusion.Write(os.Stdout)
+		if err != nil {
+			panic(err)
+		}
+	}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			panic(err)
+		}
+		label, costs, err := classifier.Classify(data)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Printf("%s %s", file, label)
+		for i, cost := range costs {
+			fmt.Printf(" %s=%f", classifier.Labels[i], cost)
+		}
+		fmt.Println()
+	}
+}
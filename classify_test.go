@@ -0,0 +1,83 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestClassifier(t *testing.T) {
+	root := t.TempDir()
+	classes := map[string][]string{
+		"english": {
+			"the quick brown fox jumps over the lazy dog",
+			"to be or not to be that is the question",
+			"all the world is a stage and all the men and women merely players",
+			"the dog and the fox are friends of the world",
+		},
+		"numbers": {
+			"3141592653 5897932384 6264338327 9502884197",
+			"1618033988 7498948482 0458683436 5638117720",
+			"2718281828 4590452353 6028747135 2662497757",
+			"1414213562 3730950488 0168872420 9698078569",
+		},
+	}
+	for label, documents := range classes {
+		err := os.Mkdir(filepath.Join(root, label), 0755)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i, document := range documents {
+			name := filepath.Join(root, label, strings.Repeat("x", i+1)+".txt")
+			err := os.WriteFile(name, []byte(document), 0644)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	documents, err := ReadDocuments(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(documents) != 8 {
+		t.Fatalf("%d != 8", len(documents))
+	}
+	train, test := Split(rand.New(rand.NewSource(1)), documents, .25)
+	if len(train) != 6 || len(test) != 2 {
+		t.Fatalf("%d %d", len(train), len(test))
+	}
+	classifier := NewClassifier(train, 16)
+	confusion, err := classifier.Evaluate(test)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if accuracy := confusion.Accuracy(); accuracy != 1 {
+		t.Fatalf("%f != 1 %v", accuracy, confusion.Counts)
+	}
+	label, _, err := classifier.Classify([]byte("the lazy fox and the dog"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if label != "english" {
+		t.Fatalf("%s != english", label)
+	}
+	if _, _, err := NewClassifier(nil, 16).Classify([]byte("the dog")); err == nil {
+		t.Fatal("expected an error without labels")
+	}
+	if _, err := NewClassifier(nil, 16).Evaluate(test); err == nil {
+		t.Fatal("expected an error evaluating without labels")
+	}
+	confusion, err = NewClassifier(train[:1], 16).Evaluate(test)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if confusion.Skipped == 0 {
+		t.Fatal("expected skipped documents")
+	}
+}
@@ -0,0 +1,134 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"math"
+)
+
+const (
+	// Suspicious is the surprisal in bits above which a byte is suspected to be a typo
+	Suspicious = 6
+	// Radius is how far from a suspicious byte edits are tried
+	Radius = 2
+	// Lookahead is the number of bytes after an edit included in its score
+	Lookahead = 8
+	// Alternatives is the number of most probable symbols tried for insertions and substitutions
+	Alternatives = 8
+	// Margin is how many nats more probable an edit must make the text
+	Margin = 1.0
+)
+
+// Edit is a correction
+type Edit struct {
+	Offset int    `json:"offset"`
+	Kind   string `json:"kind"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+}
+
+// Cost is the natural log probability of text after the model state
+func Cost(m *Model, text []byte) float64 {
+	m = m.Copy()
+	total := 0.0
+	for _, s := range text {
+		d := m.Predict()
+		total += math.Log(d[s])
+		m.Add(s)
+	}
+	return total
+}
+
+// Variants generates the single insert, delete, substitute and transpose edits at offset
+// with the most probable alternatives as the inserted and substituted symbols
+func Variants(text []byte, offset int, alternatives []byte) (variants [][]byte, edits []Edit) {
+	add := func(variant []byte, edit Edit) {
+		variants = append(variants, variant)
+		edits = append(edits, edit)
+	}
+	splice := func(begin, end int, with ...byte) []byte {
+		variant := make([]byte, 0, len(text)+1)
+		variant = append(variant, text[:begin]...)
+		variant = append(variant, with...)
+		return append(variant, text[end:]...)
+	}
+	for _, s := range alternatives {
+		add(splice(offset, offset, s), Edit{Offset: offset, Kind: "insert", To: string(s)})
+	}
+	if offset >= len(text) {
+		return variants, edits
+	}
+	from := string(text[offset : offset+1])
+	add(splice(offset, offset+1), Edit{Offset: offset, Kind: "delete", From: from})
+	for _, s := range alternatives {
+		if s != text[offset] {
+			add(splice(offset, offset+1, s), Edit{Offset: offset, Kind: "substitute", From: from, To: string(s)})
+		}
+	}
+	if offset+1 < len(text) && text[offset] != text[offset+1] {
+		add(splice(offset, offset+2, text[offset+1], text[offset]),
+			Edit{Offset: offset, Kind: "transpose", From: string(text[offset : offset+2]),
+				To: string([]byte{text[offset+1], text[offset]})})
+	}
+	return variants, edits
+}
+
+// Correct corrects the bytes the model finds suspicious with the most probable single edits
+func Correct(m *Model, text []byte) ([]byte, []Edit) {
+	text = bytes.Clone(text)
+	corrections := []Edit{}
+	scored := Score(m, text)
+	for i := 0; i < len(text); i++ {
+		if -scored.LogProbs[i]/math.Ln2 < Suspicious {
+			continue
+		}
+		begin, end := i-Radius, i+Radius+1
+		if begin < 0 {
+			begin = 0
+		}
+		if end > len(text) {
+			end = len(text)
+		}
+		state := m.Copy()
+		for _, s := range text[:begin] {
+			state.Add(s)
+		}
+		window := func(variant []byte, length int) []byte {
+			limit := length + Lookahead
+			if limit > len(variant) {
+				limit = len(variant)
+			}
+			return variant[begin:limit]
+		}
+		original := Cost(state, window(text, end))
+		best, bestCost := -1, original
+		var bestText []byte
+		var bestEdit Edit
+		for offset := begin; offset < end; offset++ {
+			prefix := state.Copy()
+			for _, s := range text[begin:offset] {
+				prefix.Add(s)
+			}
+			d := prefix.Predict()
+			variants, edits := Variants(text, offset, d.Top(Alternatives))
+			for j, variant := range variants {
+				cost := Cost(state, window(variant, end+len(variant)-len(text)))
+				if cost > bestCost {
+					best, bestCost, bestText, bestEdit = j, cost, variant, edits[j]
+				}
+			}
+		}
+		if best < 0 || bestCost-original < Margin {
+			continue
+		}
+		text = bestText
+		corrections = append(corrections, bestEdit)
+		scored = Score(m, text)
+		// continue at the first byte after the edit, the loop increments i
+		i = bestEdit.Offset + len(bestEdit.To) - 1
+	}
+	return text, corrections
+}
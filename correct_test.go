@@ -0,0 +1,55 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVariants(t *testing.T) {
+	variants, edits := Variants([]byte("abc"), 1, []byte("x"))
+	expected := []string{"axbc", "ac", "axc", "acb"}
+	if len(variants) != len(expected) {
+		t.Fatalf("%q", variants)
+	}
+	for i, variant := range variants {
+		if string(variant) != expected[i] {
+			t.Fatalf("%s %q != %q", edits[i].Kind, variant, expected[i])
+		}
+	}
+}
+
+func TestCorrect(t *testing.T) {
+	corpus := []byte(strings.Repeat("the quick brown fox jumps over the lazy dog. ", 8))
+	transforms := GetTransforms()
+	m := NewModel(&transforms, nil, NewSuffix(corpus), false)
+	for _, test := range []struct {
+		Text string
+		Kind string
+	}{
+		{"the quikc brown fox jumps", "transpose"},
+		{"the quick brwn fox jumps", "insert"},
+		{"the quick brown foxx jumps", "delete"},
+		{"the quick brown fox jumps ovar the lazy", "substitute"},
+	} {
+		corrected, edits := Correct(m, []byte(test.Text))
+		if !strings.HasPrefix(string(corpus), string(corrected)) {
+			t.Fatalf("%q -> %q %v", test.Text, corrected, edits)
+		}
+		if len(edits) != 1 || edits[0].Kind != test.Kind {
+			t.Fatalf("%q %v", test.Text, edits)
+		}
+	}
+	// the byte after a correction is checked too, so adjacent typos are both corrected
+	text := "the quick brown fox jumps over tvz lazy dog."
+	corrected, edits := Correct(m, []byte(text))
+	if string(corrected) != "the quick brown fox jumps over the lazy dog." {
+		t.Fatalf("%q -> %q %v", text, corrected, edits)
+	}
+	if len(edits) != 2 || edits[0].Kind != "substitute" || edits[1].Kind != "substitute" {
+		t.Fatalf("%q %v", text, edits)
+	}
+}
@@ -0,0 +1,379 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"math/bits"
+	"text/tabwriter"
+)
+
+// Counter is a kind of adaptive cdf for the Filtered mixer
+type Counter int
+
+const (
+	// SingleRate is a CDF16 with one exponential moving average
+	SingleRate Counter = iota
+	// DualRate averages a fast and a slow CDF16
+	DualRate
+	// CountAdaptive is a CDF16 that starts fast and slows down to its rate
+	CountAdaptive
+	// BitHistory predicts the bits of a symbol with bit history state machines
+	BitHistory
+)
+
+const (
+	// DualRateSpread is how much slower the slow cdf of a DualRate counter is
+	DualRateSpread = 4
+	// HistoryBits is the number of bits per count in a bit history state
+	HistoryBits = 4
+	// HistoryMax is the largest count in a bit history state
+	HistoryMax = 1<<HistoryBits - 1
+	// HistoryFixed is the fixed point precision of bit history probabilities
+	HistoryFixed = 12
+	// HistoryPath is the fixed point precision of symbol probabilities
+	HistoryPath = 24
+)
+
+// Counters are the counter names
+var Counters = [...]string{
+	SingleRate:    "single",
+	DualRate:      "dual",
+	CountAdaptive: "adaptive",
+	BitHistory:    "history",
+}
+
+// String returns the name of the counter
+func (c Counter) String() string {
+	return Counters[c]
+}
+
+// ParseCounter finds the counter with name
+func ParseCounter(name string) (Counter, error) {
+	for i, counter := range Counters {
+		if counter == name {
+			return Counter(i), nil
+		}
+	}
+	return SingleRate, fmt.Errorf("unknown counter %s", name)
+}
+
+// Maker returns the maker of the counter
+func (c Counter) Maker(verify bool) CDF16Maker {
+	switch c {
+	case DualRate:
+		return NewDualRate16(verify)
+	case CountAdaptive:
+		return NewAdaptive16(verify)
+	case BitHistory:
+		return NewHistory16(verify)
+	}
+	return NewCDF16(verify)
+}
+
+// checkCDF checks that a cdf starts at zero, ends at scale and is strictly increasing
+func checkCDF[T uint16 | uint32](model []T, scale T) error {
+	if model[0] != 0 {
+		return fmt.Errorf("cdf starts at %d", model[0])
+	}
+	if model[len(model)-1] != scale {
+		return fmt.Errorf("cdf ends at %d, expected %d", model[len(model)-1], scale)
+	}
+	for i := 1; i < len(model); i++ {
+		if a, b := model[i], model[i-1]; a <= b {
+			return fmt.Errorf("invalid cdf %v,%v <= %v,%v", i, a, i-1, b)
+		}
+	}
+	return nil
+}
+
+// verify16 checks that a cdf starts at zero, ends at the scale and is strictly increasing
+func verify16(model []uint16) {
+	if err := checkCDF(model, CDF16Scale); err != nil {
+		panic(err)
+	}
+}
+
+// DualRate16 is the average of a fast and a slow cdf
+type DualRate16 struct {
+	Fast   *CDF16
+	Slow   *CDF16
+	Model  []uint16
+	Verify bool
+}
+
+// NewDualRate16 makes DualRate16s, the fast cdf adapts at rate and the slow cdf adapts
+// DualRateSpread slower
+func NewDualRate16(verify bool) CDF16Maker {
+	cdf := NewCDF16(verify)
+	return func(size, rate int) Filtered16 {
+		d := &DualRate16{
+			Fast:   cdf(size, rate).(*CDF16),
+			Slow:   cdf(size, min(rate+DualRateSpread, CDF16Fixed)).(*CDF16),
+			Model:  make([]uint16, size+1),
+			Verify: verify,
+		}
+		d.mix()
+		return d
+	}
+}
+
+// mix averages the fast and slow cdfs, the average of two strictly increasing cdfs is strictly increasing
+func (d *DualRate16) mix() {
+	for i := range d.Model {
+		d.Model[i] = uint16((int(d.Fast.Model[i]) + int(d.Slow.Model[i])) >> 1)
+	}
+}
+
+// GetModel gets the cdf
+func (d *DualRate16) GetModel() []uint16 {
+	return d.Model
+}
+
+// Copy copies the model
+func (d *DualRate16) Copy() Filtered16 {
+	model := make([]uint16, len(d.Model))
+	copy(model, d.Model)
+	return &DualRate16{
+		Fast:   d.Fast.Copy().(*CDF16),
+		Slow:   d.Slow.Copy().(*CDF16),
+		Model:  model,
+		Verify: d.Verify,
+	}
+}
+
+// Update updates both cdfs
+func (d *DualRate16) Update(s uint16) {
+	d.Fast.Update(s)
+	d.Slow.Update(s)
+	d.mix()
+	if d.Verify {
+		verify16(d.Model)
+	}
+}
+
+// Adaptive16 is a cdf whose rate grows with the number of updates up to a limit, so it
+// starts as a running average and becomes an exponential moving average
+type Adaptive16 struct {
+	CDF16
+	Limit int
+	Count int
+}
+
+// NewAdaptive16 makes Adaptive16s, the rate starts at one and slows down to rate
+func NewAdaptive16(verify bool) CDF16Maker {
+	cdf := NewCDF16(verify)
+	return func(size, rate int) Filtered16 {
+		return &Adaptive16{
+			CDF16: *cdf(size, 1).(*CDF16),
+			Limit: rate,
+		}
+	}
+}
+
+// Copy copies the model
+func (a *Adaptive16) Copy() Filtered16 {
+	return &Adaptive16{
+		CDF16: *a.CDF16.Copy().(*CDF16),
+		Limit: a.Limit,
+		Count: a.Count,
+	}
+}
+
+// Update the cdf with a rate of about log2 of the number of updates
+func (a *Adaptive16) Update(s uint16) {
+	a.Rate = min(bits.Len(uint(a.Count))+1, a.Limit)
+	a.CDF16.Update(s)
+	if a.Rate < a.Limit {
+		a.Count++
+	}
+}
+
+// HistoryState is a bit history, the counts of zeros and ones seen in a context
+type HistoryState uint8
+
+// NewHistoryState makes a bit history from counts
+func NewHistoryState(n0, n1 int) HistoryState {
+	return HistoryState(n0<<HistoryBits | n1)
+}
+
+// Counts returns the counts of zeros and ones
+func (h HistoryState) Counts() (n0, n1 int) {
+	return int(h >> HistoryBits), int(h & HistoryMax)
+}
+
+// Next returns the state after seeing bit, counts are limited to limit and the opposite
+// count above two is halved so the history favors recent bits
+func (h HistoryState) Next(bit, limit int) HistoryState {
+	n := [2]int{}
+	n[0], n[1] = h.Counts()
+	n[bit] = min(n[bit]+1, limit)
+	if other := &n[1-bit]; *other > 2 {
+		*other = *other/2 + 1
+	}
+	return NewHistoryState(n[0], n[1])
+}
+
+// Probability returns the probability of a one bit with HistoryFixed bits of precision
+func (h HistoryState) Probability() int {
+	n0, n1 := h.Counts()
+	return ((2*n1 + 1) << HistoryFixed) / (2*(n0+n1) + 2)
+}
+
+// History16 predicts each symbol as a path of binary decisions in a tree, each node of the
+// tree has a bit history state
+type History16 struct {
+	Size   int
+	Rate   int
+	Limit  int
+	States []HistoryState
+	Paths  []int
+	Model  []uint16
+	Verify bool
+}
+
+// HistoryLimit is the count limit of the bit histories of a History16 with rate, so slow
+// rates remember longer histories
+func HistoryLimit(rate int) int {
+	return max(1, min(2*rate-1, HistoryMax))
+}
+
+// NewHistory16 makes History16s, the alphabet size must be a power of two
+func NewHistory16(verify bool) CDF16Maker {
+	return func(size, rate int) Filtered16 {
+		if err := Headroom(size, CDF16Scale); err != nil {
+			panic(err)
+		}
+		if size&(size-1) != 0 {
+			panic(fmt.Errorf("alphabet size %d is not a power of two", size))
+		}
+		h := &History16{
+			Size:   size,
+			Rate:   rate,
+			Limit:  HistoryLimit(rate),
+			States: make([]HistoryState, size),
+			Paths:  make([]int, 2*size),
+			Model:  make([]uint16, size+1),
+			Verify: verify,
+		}
+		h.cdf()
+		return h
+	}
+}
+
+// cdf computes the cdf from the probabilities of the paths through the tree, each symbol
+// has a count of at least one and the rounding remainder goes to the most probable symbol
+func (h *History16) cdf() {
+	paths := h.Paths
+	paths[1] = 1 << HistoryPath
+	for node := 1; node < h.Size; node++ {
+		p1 := h.States[node].Probability()
+		paths[2*node+1] = (paths[node] * p1) >> HistoryFixed
+		paths[2*node] = (paths[node] * (1<<HistoryFixed - p1)) >> HistoryFixed
+	}
+	leaves, total, best := paths[h.Size:], 0, 0
+	for s := range leaves {
+		leaves[s] = 1 + ((leaves[s] * (CDF16Scale - h.Size)) >> HistoryPath)
+		total += leaves[s]
+		if leaves[s] > leaves[best] {
+			best = s
+		}
+	}
+	leaves[best] += CDF16Scale - total
+	sum := 0
+	for s, f := range leaves {
+		h.Model[s] = uint16(sum)
+		sum += f
+	}
+	h.Model[h.Size] = uint16(sum)
+}
+
+// GetModel gets the cdf
+func (h *History16) GetModel() []uint16 {
+	return h.Model
+}
+
+// Copy copies the model
+func (h *History16) Copy() Filtered16 {
+	states := make([]HistoryState, len(h.States))
+	copy(states, h.States)
+	model := make([]uint16, len(h.Model))
+	copy(model, h.Model)
+	return &History16{
+		Size:   h.Size,
+		Rate:   h.Rate,
+		Limit:  h.Limit,
+		States: states,
+		Paths:  make([]int, len(h.Paths)),
+		Model:  model,
+		Verify: h.Verify,
+	}
+}
+
+// Update updates the bit histories along the path of s
+func (h *History16) Update(s uint16) {
+	if int(s) >= h.Size {
+		panic(fmt.Sprintf("symbol %d is not less than %d", s, h.Size))
+	}
+	node := 1
+	for bit := bits.Len(uint(h.Size)) - 2; bit >= 0; bit-- {
+		b := int(s>>bit) & 1
+		h.States[node] = h.States[node].Next(b, h.Limit)
+		node = 2*node + b
+	}
+	h.cdf()
+	if h.Verify {
+		verify16(h.Model)
+	}
+}
+
+// CounterCost is the cost of the predictions of each filter of a counter
+type CounterCost struct {
+	Counter Counter
+	Bits    []float64
+}
+
+// CompareCounters computes the bits per byte of the predictions of each filter of a
+// Filtered mixer for each counter over data
+func CompareCounters(data []byte) []CounterCost {
+	costs := make([]CounterCost, len(Counters))
+	for i := range costs {
+		f := NewFiltered(Counter(i))
+		f.Add(0)
+		costs[i].Counter = Counter(i)
+		costs[i].Bits = make([]float64, len(f.Filters))
+		for _, s := range data {
+			for j, d := range f.Predict() {
+				costs[i].Bits[j] += d.Cost(s)
+			}
+			f.Add(s)
+		}
+		for j := range costs[i].Bits {
+			costs[i].Bits[j] /= float64(len(data))
+		}
+	}
+	return costs
+}
+
+// WriteCounterCosts writes a table of the bits per byte of each counter and rate
+func WriteCounterCosts(w io.Writer, costs []CounterCost) error {
+	writer := tabwriter.NewWriter(w, 0, 8, 1, ' ', tabwriter.AlignRight)
+	fmt.Fprint(writer, "counter\\rate\t")
+	for i := range costs[0].Bits {
+		fmt.Fprintf(writer, "%d\t", i+1)
+	}
+	fmt.Fprintln(writer, "best\t")
+	for _, cost := range costs {
+		fmt.Fprintf(writer, "%s\t", cost.Counter)
+		best := cost.Bits[0]
+		for _, v := range cost.Bits {
+			fmt.Fprintf(writer, "%.4f\t", v)
+			best = min(best, v)
+		}
+		fmt.Fprintf(writer, "%.4f\t\n", best)
+	}
+	return writer.Flush()
+}
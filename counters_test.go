@@ -0,0 +1,124 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestCounters(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := range Counters {
+		counter := Counter(i)
+		parsed, err := ParseCounter(counter.String())
+		if err != nil || parsed != counter {
+			t.Fatalf("%s parsed as %s, %v", counter, parsed, err)
+		}
+		for _, size := range []int{2, 16, 256} {
+			for rate := 1; rate < 9; rate++ {
+				filtered := counter.Maker(true)(size, rate)
+				cp := filtered.Copy()
+				for j := 0; j < 512; j++ {
+					s := uint16(rng.Intn(size))
+					if j&1 == 1 {
+						s = 1
+					}
+					filtered.Update(s)
+					cp.Update(s)
+				}
+				a, b := filtered.GetModel(), cp.GetModel()
+				for j, v := range a {
+					if v != b[j] {
+						t.Fatalf("%s copy %d != %d", counter, v, b[j])
+					}
+				}
+				if p := a[2] - a[1]; p < CDF16Scale/uint16(size) {
+					t.Fatalf("%s rate %d did not learn the frequent symbol, count %d", counter, rate, p)
+				}
+			}
+		}
+	}
+	if _, err := ParseCounter("none"); err == nil {
+		t.Fatal("expected an unknown counter error")
+	}
+}
+
+func TestHistoryState(t *testing.T) {
+	state := HistoryState(0)
+	for i := 0; i < 32; i++ {
+		state = state.Next(1, HistoryMax)
+	}
+	if n0, n1 := state.Counts(); n0 != 0 || n1 != HistoryMax {
+		t.Fatalf("counts are %d and %d", n0, n1)
+	}
+	p := state.Probability()
+	state = state.Next(0, HistoryMax)
+	if n0, n1 := state.Counts(); n0 != 1 || n1 != HistoryMax/2+1 {
+		t.Fatalf("counts are %d and %d", n0, n1)
+	}
+	if q := state.Probability(); q >= p {
+		t.Fatalf("probability %d did not go down from %d", q, p)
+	}
+}
+
+func TestCounterSnapshot(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := range Counters {
+		f := NewFiltered(Counter(i))
+		for j := 0; j < 256; j++ {
+			f.Add(byte(rng.Intn(16)))
+		}
+		data, err := f.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		g := &Filtered{}
+		if err := g.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		for j := 0; j < 16; j++ {
+			s := byte(rng.Intn(16))
+			f.Add(s)
+			g.Add(s)
+		}
+		for j := range f.Filters {
+			a, b := f.Filters[j].GetModel(), g.Filters[j].GetModel()
+			for k, v := range a {
+				if v != b[k] {
+					t.Fatalf("%s filter %d: %d != %d", Counter(i), j, v, b[k])
+				}
+			}
+		}
+	}
+}
+
+func TestCompareCounters(t *testing.T) {
+	data := []byte(strings.Repeat("the quick brown fox jumps over the lazy dog. ", 16))
+	costs := CompareCounters(data)
+	if len(costs) != len(Counters) {
+		t.Fatalf("%d costs, expected %d", len(costs), len(Counters))
+	}
+	for _, cost := range costs {
+		best := cost.Bits[0]
+		for rate, bits := range cost.Bits {
+			if bits <= 0 || math.IsInf(bits, 0) || math.IsNaN(bits) {
+				t.Fatalf("%s rate %d has %f bits per byte", cost.Counter, rate+1, bits)
+			}
+			best = min(best, bits)
+		}
+		if best >= 8 {
+			t.Fatalf("%s has at best %f bits per byte", cost.Counter, best)
+		}
+	}
+	buffer := bytes.Buffer{}
+	if err := WriteCounterCosts(&buffer, costs); err != nil {
+		t.Fatal(err)
+	}
+	t.Log("\n" + buffer.String())
+}
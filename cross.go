@@ -0,0 +1,119 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+)
+
+const (
+	// CrossBits is the number of index bits in the cross symbol table
+	CrossBits = 24
+	// End terminates a target
+	End = '\n'
+)
+
+// Pair is an aligned source and target
+type Pair struct {
+	Source []byte
+	Target []byte
+}
+
+// ReadPairs reads tab separated source and target pairs, one per line
+func ReadPairs(r io.Reader) ([]Pair, error) {
+	pairs := []Pair{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		tab := bytes.IndexByte(line, '\t')
+		if tab < 0 {
+			return nil, fmt.Errorf("line %d has no tab", len(pairs)+1)
+		}
+		pairs = append(pairs, Pair{
+			Source: bytes.Clone(line[:tab]),
+			Target: bytes.Clone(line[tab+1:]),
+		})
+	}
+	return pairs, scanner.Err()
+}
+
+// ReadParallel reads line aligned source and target files
+func ReadParallel(source, target io.Reader) ([]Pair, error) {
+	pairs := []Pair{}
+	a, b := bufio.NewScanner(source), bufio.NewScanner(target)
+	for a.Scan() {
+		if !b.Scan() {
+			return nil, fmt.Errorf("target has fewer lines than source")
+		}
+		pairs = append(pairs, Pair{
+			Source: bytes.Clone(a.Bytes()),
+			Target: bytes.Clone(b.Bytes()),
+		})
+	}
+	if err := a.Err(); err != nil {
+		return nil, err
+	}
+	if err := b.Err(); err != nil {
+		return nil, err
+	}
+	if b.Scan() {
+		return nil, fmt.Errorf("target has more lines than source")
+	}
+	return pairs, nil
+}
+
+// At returns the source symbol aligned with step i
+func At(source []byte, i int) byte {
+	if i < len(source) {
+		return source[i]
+	}
+	return 0
+}
+
+// TrainCross trains the symbol table on the cross mixed vectors of the pairs
+// At step i the mixer has seen source[:i+1] and target[:i], and the slot holds target[i]
+func TrainCross(pairs []Pair, symbols *Symbols) {
+	transforms := GetTransforms()
+	for _, pair := range pairs {
+		m := NewCrossFiltered()
+		m.Add(0, 0)
+		last := byte(0)
+		for i, v := range append(bytes.Clone(pair.Target), End) {
+			m.Add(At(pair.Source, i), last)
+			vv := m.Mix()
+			keys := Keys(&transforms, &vv)
+			symbols.Set(&keys, v)
+			last = v
+		}
+	}
+}
+
+// Translate greedily generates a target for the source with the cross symbol table
+func Translate(table *SymbolTable, source []byte, length int) []byte {
+	transforms := GetTransforms()
+	m := NewCrossFiltered()
+	m.Add(0, 0)
+	target, last := []byte{}, byte(0)
+	for i := 0; i < length; i++ {
+		m.Add(At(source, i), last)
+		vv := m.Mix()
+		keys := Keys(&transforms, &vv)
+		histogram := table.Search(&keys)
+		d := NewDistribution(histogram)
+		last = d.Top(1)[0]
+		if histogram[last] == 0 || last == End {
+			break
+		}
+		target = append(target, last)
+	}
+	return target
+}
@@ -0,0 +1,62 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestReadPairs(t *testing.T) {
+	pairs, err := ReadPairs(strings.NewReader("cat\tchat\n\ndog\tchien\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pairs) != 2 || string(pairs[1].Source) != "dog" || string(pairs[1].Target) != "chien" {
+		t.Fatalf("%q", pairs)
+	}
+	_, err = ReadPairs(strings.NewReader("no tab\n"))
+	if err == nil {
+		t.Fatal("expected an error for a line without a tab")
+	}
+	pairs, err = ReadParallel(strings.NewReader("cat\ndog\n"), strings.NewReader("chat\nchien\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pairs) != 2 || string(pairs[0].Target) != "chat" {
+		t.Fatalf("%q", pairs)
+	}
+	_, err = ReadParallel(strings.NewReader("cat\ndog\n"), strings.NewReader("chat\n"))
+	if err == nil {
+		t.Fatal("expected an error for misaligned files")
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	pairs := []Pair{
+		{Source: []byte("hello"), Target: []byte("HELLO")},
+		{Source: []byte("world"), Target: []byte("WORLD")},
+	}
+	symbols := NewSymbols(20)
+	TrainCross(pairs, symbols)
+	table := symbols.Table()
+	for _, pair := range pairs {
+		if target := Translate(table, pair.Source, 32); string(target) != string(pair.Target) {
+			t.Fatalf("%s != %s", target, pair.Target)
+		}
+	}
+}
+
+func TestCrossFilteredCopy(t *testing.T) {
+	a := NewCrossFiltered()
+	a.Add(1, 2)
+	b := a.Copy()
+	a.Add(3, 4)
+	b.Add(3, 4)
+	x, y := a.Mix(), b.Mix()
+	if x != y {
+		t.Fatal("copy differs from the original")
+	}
+}
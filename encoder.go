@@ -0,0 +1,131 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math/rand"
+)
+
+// Pooling is how the encoder output rows are reduced to a context vector
+type Pooling int
+
+const (
+	// MeanPooling averages the rows
+	MeanPooling Pooling = iota
+	// LastPooling takes the last row
+	LastPooling
+	// AttentionPooling weights the rows by their similarity to a learned query
+	AttentionPooling
+)
+
+// Layer is an encoder layer, attention followed by an optional feed forward network,
+// each with a residual connection and layer normalization
+type Layer struct {
+	Attention *MultiHeadAttention
+	W1        *Matrix[float32]
+	W2        *Matrix[float32]
+}
+
+// Encoder is a stack of attention layers
+type Encoder struct {
+	Layers  []Layer
+	Pooling Pooling
+	Query   []float32
+}
+
+// NewEncoder makes a new encoder with layers of multi head attention and, if hidden is
+// not zero, feed forward networks with hidden units, with weights drawn from rng
+func NewEncoder(rng *rand.Rand, layers, heads, size, hidden int, pooling Pooling) *Encoder {
+	random := func(cols, rows int) *Matrix[float32] {
+		m := NewMatrix[float32](cols, rows)
+		scale := 1 / sqrt(float32(cols))
+		for i := 0; i < cols*rows; i++ {
+			m.Data = append(m.Data, float32(rng.NormFloat64())*scale)
+		}
+		return &m
+	}
+	e := &Encoder{
+		Layers:  make([]Layer, layers),
+		Pooling: pooling,
+	}
+	for i := range e.Layers {
+		e.Layers[i].Attention = NewMultiHeadAttention(rng, heads, size)
+		if hidden > 0 {
+			e.Layers[i].W1 = random(InputSize, hidden)
+			e.Layers[i].W2 = random(hidden, InputSize)
+		}
+	}
+	if pooling == AttentionPooling {
+		e.Query = random(InputSize, 1).Data
+	}
+	return e
+}
+
+// LayerNorm normalizes each row to zero mean and unit variance
+func LayerNorm(m Matrix[float32]) Matrix[float32] {
+	for i := 0; i < m.Rows; i++ {
+		row := m.Data[i*m.Cols : (i+1)*m.Cols]
+		mean := float32(0.0)
+		for _, v := range row {
+			mean += v
+		}
+		mean /= float32(m.Cols)
+		variance := float32(0.0)
+		for _, v := range row {
+			variance += (v - mean) * (v - mean)
+		}
+		stddev := sqrt(variance/float32(m.Cols) + 1e-5)
+		for j, v := range row {
+			row[j] = (v - mean) / stddev
+		}
+	}
+	return m
+}
+
+// Forward computes the encoder output rows
+func (e *Encoder) Forward(input Matrix[float32]) Matrix[float32] {
+	x := input
+	for _, layer := range e.Layers {
+		x = LayerNorm(x.Add(layer.Attention.Forward(x)))
+		if layer.W1 != nil {
+			hidden := layer.W1.MulT(x)
+			for i, v := range hidden.Data {
+				if v < 0 {
+					hidden.Data[i] = 0
+				}
+			}
+			x = LayerNorm(x.Add(layer.W2.MulT(hidden)))
+		}
+	}
+	return x
+}
+
+// Attend encodes the input rows and pools them into a normalized context vector
+func (e *Encoder) Attend(input Matrix[float32]) [InputSize]float32 {
+	x := e.Forward(input)
+	output := [InputSize]float32{}
+	switch e.Pooling {
+	case MeanPooling:
+		for i := 0; i < x.Rows; i++ {
+			for j, v := range x.Data[i*x.Cols : (i+1)*x.Cols] {
+				output[j] += v / float32(x.Rows)
+			}
+		}
+	case LastPooling:
+		copy(output[:], x.Data[(x.Rows-1)*x.Cols:])
+	case AttentionPooling:
+		weights := NewMatrix(InputSize, 1, e.Query...).MulT(x)
+		for i := range weights.Data {
+			weights.Data[i] /= sqrt(InputSize)
+		}
+		softmax(weights.Data)
+		for i, w := range weights.Data {
+			for j, v := range x.Data[i*x.Cols : (i+1)*x.Cols] {
+				output[j] += w * v
+			}
+		}
+	}
+	return Normalize(output)
+}
@@ -0,0 +1,53 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestEncoder(t *testing.T) {
+	f := NewFiltered()
+	for _, v := range []byte("hello world") {
+		f.Add(v)
+	}
+	outputs := [][InputSize]float32{}
+	for _, pooling := range []Pooling{MeanPooling, LastPooling, AttentionPooling} {
+		f.Attention = NewEncoder(rand.New(rand.NewSource(1)), 2, 2, 16, 64, pooling)
+		x := f.Mix()
+		if norm := NCS(x[:], x[:]); math.Abs(float64(norm)-1) > 1e-5 {
+			t.Fatalf("%f != 1", norm)
+		}
+		if y := f.Mix(); x != y {
+			t.Fatal("encoder is not deterministic")
+		}
+		outputs = append(outputs, x)
+	}
+	if outputs[0] == outputs[1] || outputs[1] == outputs[2] {
+		t.Fatal("poolings are not distinct")
+	}
+
+	x := LayerNorm(NewMatrix[float32](4, 1, 1, 2, 3, 4))
+	mean := (x.Data[0] + x.Data[1] + x.Data[2] + x.Data[3]) / 4
+	if math.Abs(float64(mean)) > 1e-6 {
+		t.Fatalf("%f != 0", mean)
+	}
+}
+
+func TestHitRate(t *testing.T) {
+	data := []byte(strings.Repeat("the quick brown fox jumps over the lazy dog. ", 4))
+	for _, attention := range []Attention{nil, NewEncoder(rand.New(rand.NewSource(1)), 1, 2, 16, 0, LastPooling)} {
+		train, test := NewFiltered(), NewFiltered()
+		train.Attention, test.Attention = attention, attention
+		symbols := NewSymbols(20)
+		Train(train, data, symbols, nil, false)
+		if rate := HitRate(test, symbols.Table(), data); rate < .9 {
+			t.Fatalf("%f < .9", rate)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math"
+	"math/rand"
+
+	"github.com/pointlander/v/vector"
+)
+
+const (
+	// CDFRow is the row type of a filter CDF row
+	CDFRow = iota
+	// MarkovRow is the row type of a one hot markov row
+	MarkovRow
+)
+
+// Sinusoidal makes sinusoidal position encodings for rows scaled by scale
+// The mixer rows have norms of at most one, so a scale around 1/16 keeps the
+// encodings from saturating the attention
+func Sinusoidal(rows int, scale float32) Matrix[float32] {
+	m := NewMatrix[float32](InputSize, rows)
+	for pos := 0; pos < rows; pos++ {
+		for i := 0; i < InputSize; i++ {
+			angle := float64(pos) / math.Pow(10000, float64(2*(i/2))/InputSize)
+			if i%2 == 0 {
+				m.Data = append(m.Data, scale*float32(math.Sin(angle)))
+			} else {
+				m.Data = append(m.Data, scale*float32(math.Cos(angle)))
+			}
+		}
+	}
+	return m
+}
+
+// Random makes random position encodings for rows drawn from rng and scaled by scale, they
+// only make each row distinct like the sinusoidal encodings unless a Learned trains them
+func Random(rng *rand.Rand, rows int, scale float32) Matrix[float32] {
+	m := NewMatrix[float32](InputSize, rows)
+	for i := 0; i < rows*InputSize; i++ {
+		m.Data = append(m.Data, scale*float32(rng.NormFloat64()))
+	}
+	return m
+}
+
+// Learned is a position encoding learned by gradient descent, the cost is the cross entropy
+// of the next symbol under the softmax of the self attention output of the encoded rows
+// before it is normalized
+type Learned struct {
+	Encoding Matrix[float32]
+	Rate     float32
+}
+
+// NewLearned makes a learned encoding for rows starting from random encodings drawn from
+// rng and scaled by scale, it learns at rate
+func NewLearned(rng *rand.Rand, rows int, scale, rate float32) *Learned {
+	return &Learned{
+		Encoding: Random(rng, rows, scale),
+		Rate:     rate,
+	}
+}
+
+// Gradient computes the cost in bits of symbol following input and the gradient in nats of
+// the cost with respect to the encoding
+func (l *Learned) Gradient(input Matrix[float32], symbol byte) (float64, []float32) {
+	kernels := vector.For[float32]()
+	x := input.Add(l.Encoding)
+	n, d := x.Rows, x.Cols
+	row := func(i int) []float32 {
+		return x.Data[i*d : (i+1)*d]
+	}
+	// y is the sum over the rows i of the rows j weighted by the softmax of x_i . x_j
+	a, y := make([]float32, n*n), make([]float32, d)
+	for i := 0; i < n; i++ {
+		weights := a[i*n : (i+1)*n]
+		for j := range weights {
+			weights[j] = kernels.Dot(row(i), row(j))
+		}
+		kernels.Softmax(weights)
+		for j, weight := range weights {
+			kernels.Axpy(weight, row(j), y)
+		}
+	}
+	g := make([]float32, d)
+	copy(g, y)
+	kernels.Softmax(g)
+	cost := -math.Log2(float64(g[symbol]))
+	g[symbol]--
+	// u_j is the gradient with respect to the weights of row j
+	u := make([]float32, n)
+	for j := range u {
+		u[j] = kernels.Dot(g, row(j))
+	}
+	gradient := make([]float32, n*d)
+	for i := 0; i < n; i++ {
+		weights := a[i*n : (i+1)*n]
+		mean := kernels.Dot(weights, u)
+		for j, weight := range weights {
+			score := weight * (u[j] - mean)
+			kernels.Axpy(weight, g, gradient[j*d:(j+1)*d])
+			kernels.Axpy(score, row(j), gradient[i*d:(i+1)*d])
+			kernels.Axpy(score, row(i), gradient[j*d:(j+1)*d])
+		}
+	}
+	return cost, gradient
+}
+
+// Update moves the encoding down the gradient of the cost of symbol following input and
+// returns the cost in bits
+func (l *Learned) Update(input Matrix[float32], symbol byte) float64 {
+	cost, gradient := l.Gradient(input, symbol)
+	vector.For[float32]().Axpy(-l.Rate, gradient, l.Encoding.Data)
+	return cost
+}
+
+// Train trains the encoding on data with the rows of f and returns the average cost in bits,
+// f is encoded with the encoding as it learns
+func (l *Learned) Train(f *Filtered, data []byte) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+	f.Encoding = &l.Encoding
+	f.Add(0)
+	cost := 0.0
+	for _, s := range data {
+		cost += l.Update(f.Input(), s)
+		f.Add(s)
+	}
+	return cost / float64(len(data))
+}
+
+// RowTypes makes row type embeddings, types[i] is the type of row i, drawn from rng and scaled by scale
+func RowTypes(rng *rand.Rand, types []int, scale float32) Matrix[float32] {
+	embeddings := make(map[int][]float32)
+	m := NewMatrix[float32](InputSize, len(types))
+	for _, t := range types {
+		embedding, ok := embeddings[t]
+		if !ok {
+			embedding = make([]float32, InputSize)
+			for i := range embedding {
+				embedding[i] = scale * float32(rng.NormFloat64())
+			}
+			embeddings[t] = embedding
+		}
+		m.Data = append(m.Data, embedding...)
+	}
+	return m
+}
+
+// FilteredTypes are the row types of the Filtered mixer matrix
+func FilteredTypes() []int {
+	types := make([]int, Size+Order+1)
+	for i := Size; i < len(types); i++ {
+		types[i] = MarkovRow
+	}
+	return types
+}
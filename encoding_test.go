@@ -0,0 +1,87 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestEncoding(t *testing.T) {
+	a := NewFiltered()
+	for _, v := range []byte("hello") {
+		a.Add(v)
+	}
+	if a.Markov[0] != 'o' || a.Markov[1] != 'l' {
+		t.Fatalf("markov history not updated %v", a.Markov)
+	}
+	b := a.Copy().(*Filtered)
+	b.Markov[0], b.Markov[1] = b.Markov[1], b.Markov[0]
+	distance := func() float32 {
+		x, y := a.Mix(), b.Mix()
+		sum := float32(0.0)
+		for i := range x {
+			sum += (x[i] - y[i]) * (x[i] - y[i])
+		}
+		return sqrt(sum)
+	}
+	if d := distance(); d > 1e-5 {
+		t.Fatalf("identity attention is not permutation invariant %f", d)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	scale := float32(1.0 / 16)
+	for _, positional := range []Matrix[float32]{Sinusoidal(Size+Order+1, scale), Random(rng, Size+Order+1, scale)} {
+		encoding := positional.Add(RowTypes(rng, FilteredTypes(), scale))
+		a.Encoding, b.Encoding = &encoding, &encoding
+		if d := distance(); d < 1e-4 {
+			t.Fatalf("encoded attention is permutation invariant %f", d)
+		}
+	}
+}
+
+func TestSinusoidal(t *testing.T) {
+	m := Sinusoidal(2, 1)
+	if m.Data[0] != 0 || m.Data[1] != 1 {
+		t.Fatalf("%f %f", m.Data[0], m.Data[1])
+	}
+	if v := m.Data[InputSize]; math.Abs(float64(v)-math.Sin(1)) > 1e-6 {
+		t.Fatalf("%f != %f", v, math.Sin(1))
+	}
+}
+
+func TestLearned(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	f := NewFiltered()
+	for _, v := range []byte("the quick brown fox") {
+		f.Add(v)
+	}
+	input := f.Input()
+	l := NewLearned(rng, MixerRows, 1.0/16, 0)
+	_, gradient := l.Gradient(input, 'x')
+	for _, i := range []int{0, 7, InputSize + 'x', 9*InputSize + 'o', 15*InputSize + 'f'} {
+		const epsilon = 1e-2
+		v := l.Encoding.Data[i]
+		l.Encoding.Data[i] = v + epsilon
+		a, _ := l.Gradient(input, 'x')
+		l.Encoding.Data[i] = v - epsilon
+		b, _ := l.Gradient(input, 'x')
+		l.Encoding.Data[i] = v
+		numeric := (a - b) * math.Ln2 / (2 * epsilon)
+		if math.Abs(numeric-float64(gradient[i])) > 1e-3+.05*math.Abs(numeric) {
+			t.Fatalf("gradient %d is %f, expected %f", i, gradient[i], numeric)
+		}
+	}
+
+	data := []byte(strings.Repeat("the quick brown fox jumps over the lazy dog. ", 4))
+	l = NewLearned(rng, MixerRows, 1.0/16, .01)
+	first := l.Train(NewFiltered(), data)
+	second := l.Train(NewFiltered(), data)
+	if second >= first {
+		t.Fatalf("%f bits per byte did not go down from %f", second, first)
+	}
+}
@@ -0,0 +1,162 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	// DecayRescale is the weight at which a decaying histogram is rescaled
+	DecayRescale = 1 << 64
+	// DecayFloor is the count below which a rescaled decaying histogram drops a symbol
+	DecayFloor = 1.0 / (1 << 40)
+)
+
+// Counts is a histogram of the recent symbols that sets a row of a mixer workspace
+type Counts interface {
+	Add(s byte)
+	Touch(w *Workspace, i int, s byte)
+	Set(w *Workspace, i int)
+	Distribution() Distribution
+	Copy() Counts
+}
+
+// distribution normalizes a histogram, an empty histogram has an empty distribution
+func distribution[T byte | uint32 | float32](histogram *[256]T) (d Distribution) {
+	sum := 0.0
+	for _, v := range histogram {
+		sum += float64(v)
+	}
+	if sum == 0 {
+		return d
+	}
+	for i, v := range histogram {
+		d[i] = float64(v) / sum
+	}
+	return d
+}
+
+// Ring is a histogram of the last symbols kept in a ring buffer sized at runtime
+type Ring struct {
+	Vector [256]uint32
+	Buffer []byte
+	Index  int
+}
+
+// NewRing makes a new ring histogram of the last size symbols
+func NewRing(size int) *Ring {
+	if size < 1 {
+		panic(fmt.Errorf("ring size %d is less than 1", size))
+	}
+	return &Ring{
+		Buffer: make([]byte, size),
+	}
+}
+
+// Add adds a symbol to the ring, dropping the oldest symbol
+func (h *Ring) Add(s byte) {
+	index := (h.Index + 1) % len(h.Buffer)
+	if symbol := h.Buffer[index]; h.Vector[symbol] > 0 {
+		h.Vector[symbol]--
+	}
+	h.Buffer[index] = s
+	h.Vector[s]++
+	h.Index = index
+}
+
+// Touch marks the columns of row i in the workspace that adding s changes
+func (h *Ring) Touch(w *Workspace, i int, s byte) {
+	if w == nil {
+		return
+	}
+	w.Touch(i, h.Buffer[(h.Index+1)%len(h.Buffer)])
+	w.Touch(i, s)
+}
+
+// Set sets row i of the workspace
+func (h *Ring) Set(w *Workspace, i int) {
+	setHistogram(w, i, &h.Vector)
+}
+
+// Distribution is the normalized histogram
+func (h *Ring) Distribution() Distribution {
+	return distribution(&h.Vector)
+}
+
+// Copy copies the ring
+func (h *Ring) Copy() Counts {
+	buffer := make([]byte, len(h.Buffer))
+	copy(buffer, h.Buffer)
+	return &Ring{
+		Vector: h.Vector,
+		Buffer: buffer,
+		Index:  h.Index,
+	}
+}
+
+// Decay is a histogram where the count of a symbol halves every HalfLife symbols, the
+// counts are not decayed on every symbol, instead the weight of new symbols grows and
+// the histogram is rescaled when the weight reaches DecayRescale
+type Decay struct {
+	Vector   [256]float32
+	HalfLife float64
+	Factor   float32
+	Weight   float32
+}
+
+// NewDecay makes a new decaying histogram with a half life in symbols
+func NewDecay(halfLife float64) *Decay {
+	if !(halfLife > 0) || math.IsInf(halfLife, 0) {
+		panic(fmt.Errorf("half life %f is out of range", halfLife))
+	}
+	return &Decay{
+		HalfLife: halfLife,
+		Factor:   float32(math.Exp2(1 / halfLife)),
+		Weight:   1,
+	}
+}
+
+// Add adds a symbol to the histogram
+func (h *Decay) Add(s byte) {
+	h.Vector[s] += h.Weight
+	h.Weight *= h.Factor
+	if h.Weight < DecayRescale {
+		return
+	}
+	scale := 1 / h.Weight
+	for i, v := range h.Vector {
+		if v *= scale; v < DecayFloor {
+			v = 0
+		}
+		h.Vector[i] = v
+	}
+	h.Weight = 1
+}
+
+// Touch marks row i in the workspace as changed, all of the normalized counts change
+func (h *Decay) Touch(w *Workspace, i int, s byte) {
+	if w == nil {
+		return
+	}
+	w.InvalidateRow(i)
+}
+
+// Set sets row i of the workspace
+func (h *Decay) Set(w *Workspace, i int) {
+	setHistogram(w, i, &h.Vector)
+}
+
+// Distribution is the normalized histogram
+func (h *Decay) Distribution() Distribution {
+	return distribution(&h.Vector)
+}
+
+// Copy copies the histogram
+func (h *Decay) Copy() Counts {
+	c := *h
+	return &c
+}
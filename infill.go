@@ -0,0 +1,83 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math/rand"
+)
+
+const (
+	// InfillBits is the number of index bits in the infill symbol table
+	InfillBits = 32
+	// MaxGap is the longest gap trained for infilling
+	MaxGap = 16
+	// Warmup is the number of prefix bytes fed to the mixer before the gap
+	Warmup = 32
+	// InfillStride is the distance between the gaps trained in the corpus
+	InfillStride = 16
+)
+
+// Mirror feeds the cross mixer for predicting the byte at position i of text, where end is the
+// end of the gap. The first stream carries the byte before i and the second walks the suffix
+// backwards, so it reaches the byte right after the gap exactly when the gap is filled. Bytes
+// outside of text are zero
+func Mirror(m CrossMix, text []byte, i, end int) {
+	a, b := byte(0), byte(0)
+	if i > 0 {
+		a = text[i-1]
+	}
+	if j := 2*end - 1 - i; j < len(text) {
+		b = text[j]
+	}
+	m.Add(a, b)
+}
+
+// TrainInfill trains the symbol table on gaps of random length throughout the data, the mixer
+// is always warmed up with Warmup bytes, which are zero before the start of the data
+func TrainInfill(rng *rand.Rand, data []byte, symbols *Symbols) {
+	transforms := GetTransforms()
+	for end := MaxGap; end < len(data); end += InfillStride {
+		gap := 1 + rng.Intn(MaxGap)
+		begin := end - gap
+		m := NewCrossFiltered()
+		m.Add(0, 0)
+		for i := begin - Warmup; i < begin; i++ {
+			Mirror(m, data, i, end)
+		}
+		for i := begin; i < end; i++ {
+			Mirror(m, data, i, end)
+			vv := m.Mix()
+			keys := Keys(&transforms, &vv)
+			symbols.Set(&keys, data[i])
+		}
+	}
+}
+
+// Infill greedily generates the gap bytes between prefix and suffix with the infill symbol table,
+// the mixer is warmed up like in training and generation stops when the table has no symbol
+func Infill(table *SymbolTable, prefix, suffix []byte, gap int) []byte {
+	transforms := GetTransforms()
+	text := make([]byte, len(prefix)+gap+len(suffix))
+	copy(text, prefix)
+	copy(text[len(prefix)+gap:], suffix)
+	begin, end := len(prefix), len(prefix)+gap
+	m := NewCrossFiltered()
+	m.Add(0, 0)
+	for i := begin - Warmup; i < begin; i++ {
+		Mirror(m, text, i, end)
+	}
+	for i := begin; i < end; i++ {
+		Mirror(m, text, i, end)
+		vv := m.Mix()
+		keys := Keys(&transforms, &vv)
+		histogram := table.Search(&keys)
+		d := NewDistribution(histogram)
+		text[i] = d.Top(1)[0]
+		if histogram[text[i]] == 0 {
+			return text[begin:i]
+		}
+	}
+	return text[begin:end]
+}
@@ -0,0 +1,33 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestInfill(t *testing.T) {
+	data := []byte(strings.Repeat("the quick brown fox jumps over the lazy dog. ", 16))
+	symbols := NewSymbols(22)
+	TrainInfill(rand.New(rand.NewSource(1)), data, symbols)
+	rng := rand.New(rand.NewSource(1))
+	for end := MaxGap; end < len(data); end += InfillStride {
+		gap := 1 + rng.Intn(MaxGap)
+		if end%3 != 0 {
+			continue
+		}
+		begin := end - gap
+		middle := Infill(symbols.Table(), data[:begin], data[end:], gap)
+		if string(middle) != string(data[begin:end]) {
+			t.Fatalf("%d %q != %q", end, middle, data[begin:end])
+		}
+	}
+	// an empty table has no symbols to fill the gap with
+	if middle := Infill(NewSymbols(10).Table(), data[:8], data[12:], 4); len(middle) != 0 {
+		t.Fatalf("%q filled from an empty table", middle)
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math"
+)
+
+const (
+	// LogisticRate is the learning rate of the logistic mixer
+	LogisticRate = 0.002
+	// Epsilon is the smallest probability a predictor can assign to a symbol
+	Epsilon = 1.0 / (1 << 16)
+)
+
+// Logistic is an online logistic mixer with context selected weight sets
+// It mixes distributions in the log domain, p(s) ∝ exp(Σ w_i log p_i(s)),
+// and learns the weights by gradient descent on the coding cost
+type Logistic struct {
+	Inputs  int
+	Rate    float64
+	Weights [][]float64
+	Context int
+	Input   [][256]float64
+	Output  Distribution
+}
+
+// NewLogistic makes a new logistic mixer with a weight set for each context
+func NewLogistic(inputs, contexts int, rate float64) *Logistic {
+	weights := make([][]float64, contexts)
+	for i := range weights {
+		weights[i] = make([]float64, inputs)
+		for j := range weights[i] {
+			weights[i][j] = 1 / float64(inputs)
+		}
+	}
+	return &Logistic{
+		Inputs:  inputs,
+		Rate:    rate,
+		Weights: weights,
+		Input:   make([][256]float64, inputs),
+	}
+}
+
+// Copy copies the logistic mixer
+func (l *Logistic) Copy() *Logistic {
+	weights := make([][]float64, len(l.Weights))
+	for i := range weights {
+		weights[i] = make([]float64, len(l.Weights[i]))
+		copy(weights[i], l.Weights[i])
+	}
+	input := make([][256]float64, len(l.Input))
+	copy(input, l.Input)
+	return &Logistic{
+		Inputs:  l.Inputs,
+		Rate:    l.Rate,
+		Weights: weights,
+		Context: l.Context,
+		Input:   input,
+		Output:  l.Output,
+	}
+}
+
+// Mix mixes the input distributions with the weight set selected by context
+func (l *Logistic) Mix(inputs []Distribution, context int) Distribution {
+	if len(inputs) != l.Inputs {
+		panic("incorrect number of inputs")
+	}
+	l.Context = context
+	weights := l.Weights[context]
+	var logits [256]float64
+	for i := range inputs {
+		for s, p := range inputs[i] {
+			if p < Epsilon {
+				p = Epsilon
+			}
+			l.Input[i][s] = math.Log(p)
+			logits[s] += weights[i] * l.Input[i][s]
+		}
+	}
+	max := math.Inf(-1)
+	for _, v := range logits {
+		if v > max {
+			max = v
+		}
+	}
+	sum := 0.0
+	for s, v := range logits {
+		l.Output[s] = math.Exp(v - max)
+		sum += l.Output[s]
+	}
+	for s := range l.Output {
+		l.Output[s] /= sum
+	}
+	return l.Output
+}
+
+// Update updates the weights of the last mix with the observed symbol
+func (l *Logistic) Update(s byte) {
+	weights := l.Weights[l.Context]
+	for i := range weights {
+		expected := 0.0
+		for j, p := range l.Output {
+			expected += p * l.Input[i][j]
+		}
+		weights[i] += l.Rate * (l.Input[i][s] - expected)
+	}
+}
@@ -0,0 +1,51 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestLogistic(t *testing.T) {
+	good, bad := Distribution{}, Distribution{}
+	good['a'] = .9
+	good['b'] = .1
+	bad['b'] = 1
+	l := NewLogistic(2, 1, LogisticRate)
+	first := 0.0
+	for i := 0; i < 1024; i++ {
+		d := l.Mix([]Distribution{good, bad}, 0)
+		if i == 0 {
+			first = d.Cost('a')
+		}
+		l.Update('a')
+	}
+	if l.Weights[0][0] <= l.Weights[0][1] {
+		t.Fatalf("%f <= %f", l.Weights[0][0], l.Weights[0][1])
+	}
+	d := l.Mix([]Distribution{good, bad}, 0)
+	if last := d.Cost('a'); last >= first {
+		t.Fatalf("%f >= %f", last, first)
+	}
+	sum := 0.0
+	for _, v := range d {
+		sum += v
+	}
+	if math.Abs(sum-1) > 1e-9 {
+		t.Fatalf("%f != 1", sum)
+	}
+}
+
+func TestModelLogistic(t *testing.T) {
+	transforms := GetTransforms()
+	data := []byte(strings.Repeat("abcabcabd", 64))
+	a := Evaluate(NewModel(&transforms, nil, nil, false), data)
+	b := Evaluate(NewModel(&transforms, nil, nil, true), data)
+	if b >= a {
+		t.Fatalf("%f >= %f", b, a)
+	}
+}
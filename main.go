@@ -13,7 +13,6 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
-	"math"
 	"math/rand"
 	"os"
 
@@ -107,14 +106,187 @@ func GetTransforms() (transforms [512][256]float32) {
 	return transforms
 }
 
+// Corpus loads the training corpus
+func Corpus() []byte {
+	file, err := Data.Open("books/100.txt.utf-8.bz2")
+	if err != nil {
+		panic(err)
+	}
+	defer file.Close()
+	reader := bzip2.NewReader(file)
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		panic(err)
+	}
+	return data
+}
+
+// ReadInput reads a file, - is stdin
+func ReadInput(name string) ([]byte, error) {
+	if name == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(name)
+}
+
 var (
 	// FlagInfer is the inference mode
 	FlagInfer = flag.String("infer", "", "inference mode")
+	// FlagModel is the symbol table written by training, the right to left table is written next to it
+	FlagModel = flag.String("model", "model.bin", "symbol table written by training")
+	// FlagOffsets builds the offset table when training
+	FlagOffsets = flag.Bool("offsets", false, "build the offset table next to the model when training")
+	// FlagRetrieve is the retrieval mode
+	FlagRetrieve = flag.String("retrieve", "", "retrieval mode using an offset table")
+	// FlagSuffix is the suffix array file, built when training and used for backoff when inferring
+	FlagSuffix = flag.String("suffix", "", "suffix array file for exact match backoff")
+	// FlagMix enables the logistic mixer
+	FlagMix = flag.Bool("mix", false, "mix all of the predictors with the logistic mixer")
+	// FlagEvaluate is the file to compute the bits per byte of
+	FlagEvaluate = flag.String("evaluate", "", "evaluate the bits per byte of a file")
+	// FlagBits trains a table of the binary decisions of each byte
+	FlagBits = flag.Bool("bits", false, "train a binary decomposition table, the table records its mode for evaluation")
+	// FlagBinary is the file to evaluate the adaptive binary cdfs on
+	FlagBinary = flag.String("binary", "", "evaluate the bits per byte of the adaptive binary cdfs on a file")
+	// FlagCounter is the adaptive counter of the filtered mixer
+	FlagCounter = flag.String("counter", "single", "filtered mixer counter for training: single, dual, adaptive or history, inference uses the counter of the table")
+	// FlagCounters is the file to compare the counters on
+	FlagCounters = flag.String("counters", "", "compare the bits per byte of the counters on a file")
+	// FlagBeam is the beam search width
+	FlagBeam = flag.Int("beam", 0, "beam search width")
+	// FlagBest is the number of samples for best of n decoding
+	FlagBest = flag.Int("best", 0, "best of n decoding")
+	// FlagPenalty is the length penalty
+	FlagPenalty = flag.Float64("penalty", 0, "length penalty for ranking sequences")
+	// FlagScore is the file of candidates to score and rank, - is stdin
+	FlagScore = flag.String("score", "", "score and rank candidates, one per line or JSONL")
+	// FlagSurprise is the document to report the surprisal of, - is stdin
+	FlagSurprise = flag.String("surprise", "", "per byte and per line surprisal report")
+	// FlagFormat is the output format of the surprisal report
+	FlagFormat = flag.String("format", "ansi", "surprisal report format: json, tsv or ansi")
+	// FlagCorrect is the text to correct, - is stdin
+	FlagCorrect = flag.String("correct", "", "correct typos in a file")
+	// FlagClassify is the directory of labeled subdirectories to train a classifier on
+	FlagClassify = flag.String("classify", "", "train a classifier on labeled subdirectories and classify the file arguments")
+	// FlagHoldout is the fraction of documents held out for evaluating the classifier
+	FlagHoldout = flag.Float64("holdout", .2, "fraction of documents held out for evaluation")
+	// FlagCross trains the cross table on tab separated pairs, or on source lines with -target
+	FlagCross = flag.String("cross", "", "train the cross table on aligned pairs")
+	// FlagTarget is the line aligned target file for -cross
+	FlagTarget = flag.String("target", "", "line aligned target file for cross training")
+	// FlagTranslate is the source text to generate a target for with a cross table
+	FlagTranslate = flag.String("translate", "", "generate a target for the source text with a cross table")
+	// FlagReverse trains the right to left model alongside the forward one, and scores in both directions
+	FlagReverse = flag.Bool("reverse", false, "train and score with a right to left model")
+	// FlagFIM trains the fill in the middle table
+	FlagFIM = flag.Bool("fim", false, "train the fill in the middle table")
+	// FlagBefore is the text before the gap to fill in
+	FlagBefore = flag.String("before", "", "text before the gap to fill in with an infill table")
+	// FlagAfter is the text after the gap to fill in
+	FlagAfter = flag.String("after", "", "text after the gap to fill in with an infill table")
+	// FlagGap is the length of the gap to fill in
+	FlagGap = flag.Int("gap", 8, "length of the gap to fill in")
 )
 
 func main() {
 	flag.Parse()
 
+	counter, err := ParseCounter(*FlagCounter)
+	if err != nil {
+		panic(err)
+	}
+
+	if *FlagCounters != "" {
+		data, err := os.ReadFile(*FlagCounters)
+		if err != nil {
+			panic(err)
+		}
+		err = WriteCounterCosts(os.Stdout, CompareCounters(data))
+		if err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	if *FlagBinary != "" {
+		data, err := os.ReadFile(*FlagBinary)
+		if err != nil {
+			panic(err)
+		}
+		fmt.Println(EvaluateBinary(NewFiltered(counter), NewBinary(BinaryBits, BinaryRate, false), data))
+		return
+	}
+
+	if *FlagCross != "" {
+		var pairs []Pair
+		source, err := os.Open(*FlagCross)
+		if err != nil {
+			panic(err)
+		}
+		defer source.Close()
+		if *FlagTarget != "" {
+			target, err := os.Open(*FlagTarget)
+			if err != nil {
+				panic(err)
+			}
+			defer target.Close()
+			pairs, err = ReadParallel(source, target)
+			if err != nil {
+				panic(err)
+			}
+		} else {
+			pairs, err = ReadPairs(source)
+			if err != nil {
+				panic(err)
+			}
+		}
+		symbols := NewSymbols(CrossBits)
+		TrainCross(pairs, symbols)
+		out, err := os.Create("cross.bin")
+		if err != nil {
+			panic(err)
+		}
+		defer out.Close()
+		err = symbols.Write(out, TableInfo{})
+		if err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	if *FlagFIM {
+		symbols := NewSymbols(InfillBits)
+		TrainInfill(rand.New(rand.NewSource(1)), Corpus(), symbols)
+		out, err := os.Create("infill.bin")
+		if err != nil {
+			panic(err)
+		}
+		defer out.Close()
+		err = symbols.Write(out, TableInfo{})
+		if err != nil {
+			panic(err)
+		}
+		return
+	}
+
+	if *FlagClassify != "" {
+		Classify(*FlagClassify, *FlagHoldout, flag.Args())
+		return
+	}
+
+	if *FlagRetrieve != "" {
+		corpus := Corpus()
+		spans := Retrieve(*FlagRetrieve, corpus, "What is love?", 128)
+		for _, span := range spans {
+			fmt.Printf("%s", span.Text)
+		}
+		fmt.Println()
+		for _, span := range spans {
+			fmt.Printf("%q <- %s\n", span.Text, span.Source(corpus))
+		}
+		return
+	}
+
 	if *FlagInfer != "" {
 		rng := rand.New(rand.NewSource(1))
 		db, err := os.Open(*FlagInfer)
@@ -122,93 +294,203 @@ func main() {
 			panic(err)
 		}
 		defer db.Close()
+		table, err := OpenSymbols(db)
+		if err != nil {
+			panic(err)
+		}
+		if table.Bits && (*FlagTranslate != "" || *FlagBefore != "" || *FlagAfter != "") {
+			panic(fmt.Errorf("%s is a bit table, translation and infill need a symbol table", *FlagInfer))
+		}
+		if *FlagTranslate != "" {
+			fmt.Printf("%s\n", Translate(table, []byte(*FlagTranslate), 1024))
+			return
+		}
+		if *FlagBefore != "" || *FlagAfter != "" {
+			fmt.Printf("%s\n", Infill(table, []byte(*FlagBefore), []byte(*FlagAfter), *FlagGap))
+			return
+		}
 		transforms := GetTransforms()
-		m, input := NewFiltered(), "What is love?"
-		for _, v := range []byte(input) {
-			m.Add(v)
+		model := NewModel(&transforms, table, OpenSuffix(*FlagSuffix), *FlagMix)
+		model.UseCounter(table.Counter)
+		var bidirectional *Bidirectional
+		if *FlagReverse {
+			reverse, err := os.Open(ReverseName(*FlagInfer))
+			if err != nil {
+				panic(err)
+			}
+			defer reverse.Close()
+			table, err := OpenSymbols(reverse)
+			if err != nil {
+				panic(err)
+			}
+			suffix := ""
+			if *FlagSuffix != "" {
+				suffix = ReverseName(*FlagSuffix)
+			}
+			bidirectional = &Bidirectional{
+				Forward:  model,
+				Backward: NewModel(&transforms, table, OpenSuffix(suffix), *FlagMix),
+			}
+			bidirectional.Backward.UseCounter(table.Counter)
 		}
-		for i := 0; i < 128; i++ {
-			var indexes [len(transforms)]int64
-			vv := m.Mix()
-			for i := range transforms {
-				indexes[i] = int64(math.Float32bits(2*float32(i) + vector.Dot(vv[:], transforms[i][:])))
-			}
-			var histogram [256]uint
-			found := false
-			for i := 1; i < 1024 && !found; i *= 2 {
-				for j := range indexes {
-					begin, end := indexes[j]-int64(i), indexes[j]+int64(i)
-					if begin < 0 {
-						begin = 0
-					}
-					if end > math.MaxUint32 {
-						end = math.MaxUint32
-					}
-					_, err := db.Seek(begin, 0)
-					if err != nil {
-						panic(err)
-					}
-					buffer := make([]byte, end-begin+1)
-					_, err = db.Read(buffer)
+		if *FlagEvaluate != "" {
+			data, err := os.ReadFile(*FlagEvaluate)
+			if err != nil {
+				panic(err)
+			}
+			fmt.Println(Evaluate(model, data))
+			return
+		}
+		if *FlagSurprise != "" {
+			document, err := ReadInput(*FlagSurprise)
+			if err != nil {
+				panic(err)
+			}
+			err = Surprise(model, document).Write(os.Stdout, *FlagFormat)
+			if err != nil {
+				panic(err)
+			}
+			return
+		}
+		if *FlagCorrect != "" {
+			text, err := ReadInput(*FlagCorrect)
+			if err != nil {
+				panic(err)
+			}
+			corrected, edits := Correct(model, text)
+			fmt.Printf("%s", corrected)
+			for _, edit := range edits {
+				fmt.Fprintf(os.Stderr, "%d %s %q -> %q\n", edit.Offset, edit.Kind, edit.From, edit.To)
+			}
+			return
+		}
+		if *FlagScore != "" {
+			in := os.Stdin
+			if *FlagScore != "-" {
+				in, err = os.Open(*FlagScore)
+				if err != nil {
+					panic(err)
+				}
+				defer in.Close()
+			}
+			candidates, err := ReadCandidates(in)
+			if err != nil {
+				panic(err)
+			}
+			encoder := json.NewEncoder(os.Stdout)
+			if bidirectional != nil {
+				for _, scored := range bidirectional.Rank(candidates) {
+					err := encoder.Encode(scored)
 					if err != nil {
 						panic(err)
 					}
-					for _, v := range buffer {
-						if v != 0 {
-							found = true
-							histogram[v]++
-						}
-					}
 				}
+				return
 			}
-			sum := uint(0)
-			for _, v := range histogram {
-				sum += v
-			}
-			total, selected, index := 0.0, rng.Float64(), 0
-			for i, v := range histogram {
-				total += float64(v) / float64(sum)
-				if selected < total {
-					index = i
-					break
+			for _, scored := range Rank(model, candidates) {
+				err := encoder.Encode(scored)
+				if err != nil {
+					panic(err)
 				}
 			}
-			fmt.Printf("%c", byte(index))
-			m.Add(byte(index))
+			return
+		}
+		for _, v := range []byte("What is love?") {
+			model.Add(v)
+		}
+		if *FlagBeam > 0 || *FlagBest > 0 {
+			var sequences []Sequence
+			if *FlagBeam > 0 {
+				sequences = Beam(model, *FlagBeam, 128, *FlagPenalty, -1)
+			} else {
+				sequences = BestOfN(rng, model, *FlagBest, 128, *FlagPenalty, -1)
+			}
+			for _, sequence := range sequences {
+				fmt.Printf("%f %q\n", sequence.Penalized(*FlagPenalty), sequence.Text)
+			}
+			return
+		}
+		for i := 0; i < 128; i++ {
+			d := model.Predict()
+			s := d.Sample(rng)
+			fmt.Printf("%c", s)
+			model.Add(s)
 		}
 		return
 	}
 
-	var test [4 * 1024 * 1024 * 1024]byte
-	file, err := Data.Open("books/100.txt.utf-8.bz2")
-	if err != nil {
-		panic(err)
+	data := Corpus()
+	symbols := NewSymbols(32)
+	var offsets *Offsets
+	if *FlagOffsets {
+		offsets = NewOffsets(OffsetBits)
 	}
-	defer file.Close()
-	reader := bzip2.NewReader(file)
-	data, err := io.ReadAll(reader)
-	if err != nil {
-		panic(err)
+	info := TableInfo{Counter: uint32(counter)}
+	if *FlagBits {
+		info.Bits = 1
+		TrainBits(NewFiltered(counter), data, symbols, true)
+	} else {
+		Train(NewFiltered(counter), data, symbols, offsets, true)
 	}
-	m := NewFiltered()
-	m.Add(0)
-	transforms := GetTransforms()
-	for j, v := range data {
-		vv := m.Mix()
-		for i := range transforms {
-			x := math.Float32bits(2*float32(i) + vector.Dot(vv[:], transforms[i][:]))
-			test[x] = v
+	if *FlagSuffix != "" {
+		out, err := os.Create(*FlagSuffix)
+		if err != nil {
+			panic(err)
+		}
+		defer out.Close()
+		err = NewSuffix(data).Write(out)
+		if err != nil {
+			panic(err)
 		}
-		m.Add(v)
-		fmt.Println(float64(j) / float64(len(data)))
 	}
-	out, err := os.Create("model.bin")
+	if offsets != nil {
+		out, err := os.Create(OffsetsName(*FlagModel))
+		if err != nil {
+			panic(err)
+		}
+		defer out.Close()
+		err = offsets.Write(out, info)
+		if err != nil {
+			panic(err)
+		}
+	}
+	out, err := os.Create(*FlagModel)
 	if err != nil {
 		panic(err)
 	}
 	defer out.Close()
-	_, err = out.Write(test[:])
+	err = symbols.Write(out, info)
 	if err != nil {
 		panic(err)
 	}
+
+	if *FlagReverse {
+		reversed := Reverse(data)
+		clear(symbols.Slots)
+		if *FlagBits {
+			TrainBits(NewFiltered(counter), reversed, symbols, true)
+		} else {
+			Train(NewFiltered(counter), reversed, symbols, nil, true)
+		}
+		if *FlagSuffix != "" {
+			out, err := os.Create(ReverseName(*FlagSuffix))
+			if err != nil {
+				panic(err)
+			}
+			defer out.Close()
+			err = NewSuffix(reversed).Write(out)
+			if err != nil {
+				panic(err)
+			}
+		}
+		out, err := os.Create(ReverseName(*FlagModel))
+		if err != nil {
+			panic(err)
+		}
+		defer out.Close()
+		err = symbols.Write(out, info)
+		if err != nil {
+			panic(err)
+		}
+	}
 }
@@ -11,114 +11,300 @@ import (
 	"github.com/pointlander/v/vector"
 )
 
-const (
-	// S is the scaling factor for the softmax
-	S = 1.0 - 1e38*math.SmallestNonzeroFloat32
-)
+// Float is the constraint on matrix element types
+type Float = vector.Float
 
-// Matrix is a float64 matrix
-type Matrix struct {
+// Matrix is a float32 or float64 matrix
+type Matrix[T Float] struct {
 	Cols int
 	Rows int
-	Data []float32
+	Data []T
 }
 
-// NewMatrix creates a new float32 matrix
-func NewMatrix(cols, rows int, data ...float32) Matrix {
+// NewMatrix creates a new float32 or float64 matrix
+func NewMatrix[T Float](cols, rows int, data ...T) Matrix[T] {
 	if data == nil {
-		data = make([]float32, 0, cols*rows)
+		data = make([]T, 0, cols*rows)
 	}
-	return Matrix{
+	return Matrix[T]{
 		Cols: cols,
 		Rows: rows,
 		Data: data,
 	}
 }
 
+// Convert converts a matrix to another element type
+func Convert[U, T Float](m Matrix[T]) Matrix[U] {
+	o := NewMatrix[U](m.Cols, m.Rows)
+	for _, value := range m.Data {
+		o.Data = append(o.Data, U(value))
+	}
+	return o
+}
+
 // MulT multiplies two matrices and computes the transpose
-func (m Matrix) MulT(n Matrix) Matrix {
+func (m Matrix[T]) MulT(n Matrix[T]) Matrix[T] {
 	if m.Cols != n.Cols {
 		panic(fmt.Errorf("%d != %d", m.Cols, n.Cols))
 	}
+	kernels := vector.For[T]()
 	columns := m.Cols
-	o := Matrix{
+	o := Matrix[T]{
 		Cols: m.Rows,
 		Rows: n.Rows,
-		Data: make([]float32, 0, m.Rows*n.Rows),
+		Data: make([]T, 0, m.Rows*n.Rows),
 	}
 	lenn, lenm := len(n.Data), len(m.Data)
 	for i := 0; i < lenn; i += columns {
 		nn := n.Data[i : i+columns]
 		for j := 0; j < lenm; j += columns {
 			mm := m.Data[j : j+columns]
-			o.Data = append(o.Data, vector.Dot(mm, nn))
+			o.Data = append(o.Data, kernels.Dot(mm, nn))
+		}
+	}
+	return o
+}
+
+// Mul multiplies two matrices
+func (m Matrix[T]) Mul(n Matrix[T]) Matrix[T] {
+	if m.Cols != n.Rows {
+		panic(fmt.Errorf("%d != %d", m.Cols, n.Rows))
+	}
+	kernels := vector.For[T]()
+	o := Matrix[T]{
+		Cols: n.Cols,
+		Rows: m.Rows,
+		Data: make([]T, m.Rows*n.Cols),
+	}
+	for i := 0; i < m.Rows; i++ {
+		oo := o.Data[i*n.Cols : (i+1)*n.Cols]
+		for k, value := range m.Data[i*m.Cols : (i+1)*m.Cols] {
+			kernels.Axpy(value, n.Data[k*n.Cols:(k+1)*n.Cols], oo)
 		}
 	}
 	return o
 }
 
-// Add adds two float32 matrices
-func (m Matrix) Add(n Matrix) Matrix {
+// broadcast applies an element wise operation, repeating n over m
+func (m Matrix[T]) broadcast(n Matrix[T], op func(x, y, z []T)) Matrix[T] {
 	lena, lenb := len(m.Data), len(n.Data)
-	if lena%lenb != 0 {
+	if lenb == 0 || lena%lenb != 0 {
 		panic(fmt.Errorf("%d %% %d != 0", lena, lenb))
 	}
 
-	o := Matrix{
+	o := Matrix[T]{
 		Cols: m.Cols,
 		Rows: m.Rows,
-		Data: make([]float32, 0, m.Cols*m.Rows),
+		Data: make([]T, lena),
 	}
-	for i, value := range m.Data {
-		o.Data = append(o.Data, value+n.Data[i%lenb])
+	for i := 0; i < lena; i += lenb {
+		op(m.Data[i:i+lenb], n.Data, o.Data[i:i+lenb])
 	}
 	return o
 }
 
-// Softmax calculates the softmax of the matrix rows
-func (m Matrix) Softmax(T float32) Matrix {
-	output := NewMatrix(m.Cols, m.Rows)
-	max := float32(0.0)
-	for _, v := range m.Data {
-		v /= T
-		if v > max {
-			max = v
+// Add adds two matrices
+func (m Matrix[T]) Add(n Matrix[T]) Matrix[T] {
+	return m.broadcast(n, vector.For[T]().Add)
+}
+
+// Sub subtracts two matrices
+func (m Matrix[T]) Sub(n Matrix[T]) Matrix[T] {
+	return m.broadcast(n, vector.For[T]().Sub)
+}
+
+// Hadamard computes the element wise product of two matrices
+func (m Matrix[T]) Hadamard(n Matrix[T]) Matrix[T] {
+	return m.broadcast(n, vector.For[T]().Mul)
+}
+
+// Scale multiplies a matrix by a scalar
+func (m Matrix[T]) Scale(s T) Matrix[T] {
+	o := Matrix[T]{
+		Cols: m.Cols,
+		Rows: m.Rows,
+		Data: make([]T, len(m.Data)),
+	}
+	copy(o.Data, m.Data)
+	vector.For[T]().Scale(s, o.Data)
+	return o
+}
+
+// Sum sums a matrix over the rows (axis 0) or the columns (axis 1)
+func (m Matrix[T]) Sum(axis int) Matrix[T] {
+	kernels := vector.For[T]()
+	switch axis {
+	case 0:
+		o := Matrix[T]{
+			Cols: m.Cols,
+			Rows: 1,
+			Data: make([]T, m.Cols),
+		}
+		for i := 0; i < len(m.Data); i += m.Cols {
+			kernels.Add(m.Data[i:i+m.Cols], o.Data, o.Data)
 		}
+		return o
+	case 1:
+		o := NewMatrix[T](m.Rows, 1)
+		for i := 0; i < len(m.Data); i += m.Cols {
+			o.Data = append(o.Data, kernels.Sum(m.Data[i:i+m.Cols]))
+		}
+		return o
 	}
-	s := max * S
-	for i := 0; i < len(m.Data); i += m.Cols {
-		sum := float32(0.0)
-		values := make([]float32, m.Cols)
-		for j, value := range m.Data[i : i+m.Cols] {
-			values[j] = exp(value/T - s)
-			sum += values[j]
+	panic(fmt.Errorf("invalid axis %d", axis))
+}
+
+// Row returns a view of row i
+func (m Matrix[T]) Row(i int) []T {
+	if i < 0 || i >= m.Rows {
+		panic(fmt.Errorf("row %d out of range %d", i, m.Rows))
+	}
+	return m.Data[i*m.Cols : (i+1)*m.Cols : (i+1)*m.Cols]
+}
+
+// Slice returns a view of the rows from begin up to end
+func (m Matrix[T]) Slice(begin, end int) Matrix[T] {
+	if begin < 0 || end > m.Rows || begin > end {
+		panic(fmt.Errorf("rows [%d, %d) out of range %d", begin, end, m.Rows))
+	}
+	return Matrix[T]{
+		Cols: m.Cols,
+		Rows: end - begin,
+		Data: m.Data[begin*m.Cols : end*m.Cols : end*m.Cols],
+	}
+}
+
+// Column is a view of a matrix column
+type Column[T Float] struct {
+	Stride int
+	Size   int
+	Data   []T
+}
+
+// Column returns a view of column j
+func (m Matrix[T]) Column(j int) Column[T] {
+	if j < 0 || j >= m.Cols {
+		panic(fmt.Errorf("column %d out of range %d", j, m.Cols))
+	}
+	return Column[T]{
+		Stride: m.Cols,
+		Size:   m.Rows,
+		Data:   m.Data[j:],
+	}
+}
+
+// At returns element i of the column
+func (c Column[T]) At(i int) T {
+	return c.Data[i*c.Stride]
+}
+
+// Set sets element i of the column
+func (c Column[T]) Set(i int, value T) {
+	c.Data[i*c.Stride] = value
+}
+
+// Copy copies the column into a slice
+func (c Column[T]) Copy() []T {
+	values := make([]T, c.Size)
+	for i := range values {
+		values[i] = c.Data[i*c.Stride]
+	}
+	return values
+}
+
+// Concat concatenates matrices along the rows (axis 0) or the columns (axis 1)
+func Concat[T Float](axis int, matrices ...Matrix[T]) Matrix[T] {
+	if len(matrices) == 0 {
+		panic("no matrices to concatenate")
+	}
+	first := matrices[0]
+	switch axis {
+	case 0:
+		rows := 0
+		for _, m := range matrices {
+			if m.Cols != first.Cols {
+				panic(fmt.Errorf("%d != %d", m.Cols, first.Cols))
+			}
+			rows += m.Rows
+		}
+		o := NewMatrix[T](first.Cols, rows)
+		for _, m := range matrices {
+			o.Data = append(o.Data, m.Data...)
 		}
-		for _, value := range values {
-			output.Data = append(output.Data, value/sum)
+		return o
+	case 1:
+		cols := 0
+		for _, m := range matrices {
+			if m.Rows != first.Rows {
+				panic(fmt.Errorf("%d != %d", m.Rows, first.Rows))
+			}
+			cols += m.Cols
 		}
+		o := NewMatrix[T](cols, first.Rows)
+		for i := 0; i < first.Rows; i++ {
+			for _, m := range matrices {
+				o.Data = append(o.Data, m.Data[i*m.Cols:(i+1)*m.Cols]...)
+			}
+		}
+		return o
+	}
+	panic(fmt.Errorf("invalid axis %d", axis))
+}
+
+// Softmax calculates the softmax of the matrix rows
+func (m Matrix[T]) Softmax(temperature T) Matrix[T] {
+	kernels := vector.For[T]()
+	output := NewMatrix[T](m.Cols, m.Rows)
+	for i := 0; i < len(m.Data); i += m.Cols {
+		begin := len(output.Data)
+		for _, value := range m.Data[i : i+m.Cols] {
+			output.Data = append(output.Data, value/temperature)
+		}
+		kernels.Softmax(output.Data[begin:])
 	}
 	return output
 }
 
-// Entropy calculates the entropy of the matrix rows
-func (m Matrix) Entropy() Matrix {
-	output := NewMatrix(m.Rows, 1)
+// LogSoftmax calculates the log of the softmax of the matrix rows
+func (m Matrix[T]) LogSoftmax(temperature T) Matrix[T] {
+	kernels := vector.For[T]()
+	output := NewMatrix[T](m.Cols, m.Rows)
 	for i := 0; i < len(m.Data); i += m.Cols {
-		entropy := float32(0.0)
+		begin := len(output.Data)
 		for _, value := range m.Data[i : i+m.Cols] {
-			entropy += value * log(value)
+			output.Data = append(output.Data, value/temperature)
 		}
-		output.Data = append(output.Data, -entropy)
+		kernels.LogSoftmax(output.Data[begin:])
+	}
+	return output
+}
+
+// LogSumExp calculates the log of the sum of the exponentials of the matrix rows
+func (m Matrix[T]) LogSumExp() Matrix[T] {
+	kernels := vector.For[T]()
+	output := NewMatrix[T](m.Rows, 1)
+	for i := 0; i < len(m.Data); i += m.Cols {
+		output.Data = append(output.Data, kernels.LogSumExp(m.Data[i:i+m.Cols]))
+	}
+	return output
+}
+
+// Entropy calculates the entropy of the matrix rows
+func (m Matrix[T]) Entropy() Matrix[T] {
+	kernels := vector.For[T]()
+	output := NewMatrix[T](m.Rows, 1)
+	for i := 0; i < len(m.Data); i += m.Cols {
+		output.Data = append(output.Data, kernels.Entropy(m.Data[i:i+m.Cols]))
 	}
 	return output
 }
 
 // T tramsposes a matrix
-func (m Matrix) T() Matrix {
-	o := Matrix{
+func (m Matrix[T]) T() Matrix[T] {
+	o := Matrix[T]{
 		Cols: m.Rows,
 		Rows: m.Cols,
-		Data: make([]float32, 0, m.Cols*m.Rows),
+		Data: make([]T, 0, m.Cols*m.Rows),
 	}
 	for i := 0; i < m.Cols; i++ {
 		for j := 0; j < m.Rows; j++ {
@@ -129,14 +315,14 @@ func (m Matrix) T() Matrix {
 }
 
 // AddRow adds a row to a matrix
-func (m Matrix) AddRow(row []float32) Matrix {
+func (m Matrix[T]) AddRow(row []T) Matrix[T] {
 	if len(row) != m.Cols {
 		panic("incorrect number of columns")
 	}
-	o := Matrix{
+	o := Matrix[T]{
 		Cols: m.Cols,
 		Rows: m.Rows + 1,
-		Data: make([]float32, m.Cols*m.Rows),
+		Data: make([]T, m.Cols*m.Rows),
 	}
 	copy(o.Data, m.Data)
 	o.Data = append(o.Data, row...)
@@ -144,71 +330,91 @@ func (m Matrix) AddRow(row []float32) Matrix {
 }
 
 func softmax(values []float32) {
-	max := float32(0.0)
-	for _, v := range values {
-		if v > max {
-			max = v
-		}
-	}
-	s := max * S
-	sum := float32(0.0)
-	for j, value := range values {
-		values[j] = exp(value - s)
-		sum += values[j]
-	}
-	for j, value := range values {
-		values[j] = value / sum
-	}
+	vector.Softmax(values)
 }
 
-// SelfAttention computes the self attention of Q, K, V
-func SelfAttention(input Matrix) [InputSize]float32 {
-	values := make([]float32, input.Rows)
-	V := input.T()
-	output := [InputSize]float32{}
-	for i := 0; i < input.Rows; i++ {
-		K := input.Data[i*input.Cols : (i+1)*input.Cols]
-		for j := 0; j < input.Rows; j++ {
-			Q := input.Data[j*input.Cols : (j+1)*input.Cols]
-			values[j] = vector.Dot(K, Q)
-		}
-		softmax(values)
+// Normalize normalizes a vector to unit length
+func Normalize[T Float](output [InputSize]T) [InputSize]T {
+	normalize(output[:])
+	return output
+}
 
-		for j := 0; j < V.Rows; j++ {
-			V := V.Data[j*V.Cols : (j+1)*V.Cols]
-			output[j] += vector.Dot(values, V)
-		}
-	}
-	aa := sqrt(vector.Dot(output[:], output[:]))
+// normalize normalizes a vector to unit length in place
+func normalize[T Float](output []T) {
+	aa := T(math.Sqrt(float64(vector.For[T]().Dot(output, output))))
 	for i, v := range output {
 		output[i] = v / aa
 	}
-	return output
+}
+
+// Hot returns the column of the only non zero entry of each row, or -1 if the row is dense
+func Hot[T Float](m Matrix[T]) []int {
+	hot := make([]int, m.Rows)
+	for i := range hot {
+		hot[i] = -1
+		count := 0
+		for j, v := range m.Data[i*m.Cols : (i+1)*m.Cols] {
+			if v != 0 {
+				hot[i], count = j, count+1
+			}
+		}
+		if count != 1 {
+			hot[i] = -1
+		}
+	}
+	return hot
+}
+
+// SelfAttention computes the self attention of Q, K, V
+func SelfAttention[T Float](input Matrix[T]) [InputSize]T {
+	hot := Hot(input)
+	return attention(input, input, input.T(), hot, hot, make([]T, input.Rows), make([]T, InputSize))
 }
 
 // CrossSelfAttention computes the cross self attention of a b
-func CrossSelfAttention(a, b Matrix) [InputSize]float32 {
-	values := make([]float32, a.Rows)
-	V := a.T()
-	output := [InputSize]float32{}
+func CrossSelfAttention[T Float](a, b Matrix[T]) [InputSize]T {
+	return attention(a, b, a.T(), Hot(a), Hot(b), make([]T, a.Rows), make([]T, InputSize))
+}
+
+// attention computes the attention of the rows of a over the rows of b, V is the transpose
+// of a, hota and hotb are the columns of the single non zero entries of the rows of a and b
+// or -1, values is a buffer with one entry per row and output is a buffer of InputSize
+// A row with a single non zero entry contributes one multiply to a score, which is exactly
+// what the dot product computes because every other term is zero, the weighted sum keeps
+// the dense dot products so the output does not depend on which rows are one hot
+func attention[T Float](a, b, V Matrix[T], hota, hotb []int, values, output []T) (result [InputSize]T) {
+	kernels := vector.For[T]()
+	for i := range output {
+		output[i] = 0
+	}
 	for i := 0; i < a.Rows; i++ {
 		K := a.Data[i*a.Cols : (i+1)*a.Cols]
 		for j := 0; j < b.Rows; j++ {
 			Q := b.Data[j*b.Cols : (j+1)*b.Cols]
-			values[j] = vector.Dot(K, Q)
+			switch hk, hq := hota[i], hotb[j]; {
+			case hk >= 0 && hq >= 0:
+				values[j] = 0
+				if hk == hq {
+					values[j] = K[hk] * Q[hq]
+				}
+			case hk >= 0:
+				values[j] = K[hk] * Q[hk]
+			case hq >= 0:
+				values[j] = K[hq] * Q[hq]
+			default:
+				values[j] = kernels.Dot(K, Q)
+			}
 		}
-		softmax(values)
+		kernels.Softmax(values)
 
 		for j := 0; j < V.Rows; j++ {
 			V := V.Data[j*V.Cols : (j+1)*V.Cols]
-			output[j] += vector.Dot(values, V)
+			output[j] += kernels.Dot(values, V)
 		}
 	}
-	aa := sqrt(vector.Dot(output[:], output[:]))
-	for i, v := range output {
-		output[i] = v / aa
-	}
-	return output
+	normalize(output)
+	copy(result[:], output)
+	return result
 }
 
 // CS is float32 cosine similarity
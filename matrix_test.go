@@ -0,0 +1,131 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSoftmax(t *testing.T) {
+	testSoftmax[float32](t)
+	testSoftmax[float64](t)
+}
+
+func testSoftmax[T Float](t *testing.T) {
+	t.Helper()
+	m := NewMatrix[T](3, 2, -1000, -1001, -1002, 1, 2, 3)
+	s := m.Softmax(1)
+	l := m.LogSoftmax(1)
+	z := m.LogSumExp()
+	for i := 0; i < m.Rows; i++ {
+		sum := T(0)
+		for j, value := range s.Data[i*3 : i*3+3] {
+			if math.IsNaN(float64(value)) {
+				t.Fatalf("row %d is NaN", i)
+			}
+			sum += value
+			if e := T(math.Exp(float64(l.Data[i*3+j]))); math.Abs(float64(e-value)) > 1e-5 {
+				t.Fatalf("exp(%f) != %f", l.Data[i*3+j], value)
+			}
+			if d := m.Data[i*3+j] - z.Data[i]; math.Abs(float64(d-l.Data[i*3+j])) > 1e-4 {
+				t.Fatalf("%f != %f", d, l.Data[i*3+j])
+			}
+		}
+		if math.Abs(float64(sum-1)) > 1e-5 {
+			t.Fatalf("row %d sums to %f", i, sum)
+		}
+	}
+	if s.Data[0] < s.Data[1] || s.Data[5] < s.Data[4] {
+		t.Fatal("softmax is not monotonic")
+	}
+}
+
+func TestEntropy(t *testing.T) {
+	testEntropy[float32](t)
+	testEntropy[float64](t)
+}
+
+func testEntropy[T Float](t *testing.T) {
+	t.Helper()
+	m := NewMatrix[T](4, 2, 1, 0, 0, 0, .5, .5, 0, 0)
+	e := m.Entropy()
+	if e.Data[0] != 0 {
+		t.Fatalf("%f != 0", e.Data[0])
+	}
+	if math.Abs(float64(e.Data[1])-math.Log(2)) > 1e-6 {
+		t.Fatalf("%f != log(2)", e.Data[1])
+	}
+}
+
+func TestAlgebra(t *testing.T) {
+	testAlgebra[float32](t)
+	testAlgebra[float64](t)
+}
+
+func testAlgebra[T Float](t *testing.T) {
+	t.Helper()
+	a := NewMatrix[T](3, 2, 1, 2, 3, 4, 5, 6)
+	b := NewMatrix[T](2, 3, 1, 2, 3, 4, 5, 6)
+	equal := func(m Matrix[T], cols, rows int, data ...T) {
+		t.Helper()
+		if m.Cols != cols || m.Rows != rows {
+			t.Fatalf("%dx%d != %dx%d", m.Cols, m.Rows, cols, rows)
+		}
+		for i, value := range data {
+			if m.Data[i] != value {
+				t.Fatalf("%v != %v", m.Data, data)
+			}
+		}
+	}
+	equal(a.Mul(b), 2, 2, 22, 28, 49, 64)
+	equal(b.T().MulT(a), 2, 2, a.Mul(b).Data...)
+	equal(a.Sub(a), 3, 2, 0, 0, 0, 0, 0, 0)
+	equal(a.Sub(NewMatrix[T](3, 1, 1, 1, 1)), 3, 2, 0, 1, 2, 3, 4, 5)
+	equal(a.Hadamard(a), 3, 2, 1, 4, 9, 16, 25, 36)
+	equal(a.Scale(2), 3, 2, 2, 4, 6, 8, 10, 12)
+	equal(a.Sum(0), 3, 1, 5, 7, 9)
+	equal(a.Sum(1), 2, 1, 6, 15)
+	equal(Concat(0, a, a), 3, 4, 1, 2, 3, 4, 5, 6, 1, 2, 3, 4, 5, 6)
+	equal(Concat(1, a, NewMatrix[T](1, 2, 7, 8)), 4, 2, 1, 2, 3, 7, 4, 5, 6, 8)
+
+	c := a.Scale(1)
+	c.Row(1)[0] = 10
+	c.Slice(0, 1).Row(0)[2] = 11
+	column := c.Column(1)
+	column.Set(1, 12)
+	equal(c, 3, 2, 1, 2, 11, 10, 12, 6)
+	if values := column.Copy(); len(values) != 2 || values[0] != 2 || values[1] != 12 {
+		t.Fatalf("%v != [2 12]", values)
+	}
+	if a.Data[3] != 4 {
+		t.Fatal("scale did not copy")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected a panic for mismatched dimensions")
+		}
+	}()
+	a.Mul(a)
+}
+
+func TestConvert(t *testing.T) {
+	f := NewFiltered()
+	for _, v := range []byte("hello world") {
+		f.Add(v)
+	}
+	x := f.Mix()
+	input := NewMatrix[float32](InputSize, 2)
+	input.Data = append(input.Data, x[:]...)
+	input.Data = append(input.Data, x[:]...)
+	a := SelfAttention(input)
+	b := SelfAttention(Convert[float64](input))
+	for i := range a {
+		if math.Abs(float64(a[i])-b[i]) > 1e-5 {
+			t.Fatalf("%f != %f", a[i], b[i])
+		}
+	}
+}
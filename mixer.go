@@ -6,6 +6,8 @@ package main
 
 import (
 	"fmt"
+
+	"github.com/pointlander/v/vector"
 )
 
 const (
@@ -31,6 +33,11 @@ type Mix interface {
 	Mix() [InputSize]float32
 }
 
+// Predictor is a mixer that predicts the next symbol
+type Predictor interface {
+	Predict() []Distribution
+}
+
 // Mix is a mixer
 type CrossMix interface {
 	Copy() CrossMix
@@ -38,52 +45,52 @@ type CrossMix interface {
 	Mix() [InputSize]float32
 }
 
+// CDF16 is an adaptive cdf with 16 bit entries and a scale of CDF16Scale
 type CDF16 struct {
 	Size   int
 	Rate   int
 	Model  []uint16
-	Mixin  [][]uint16
 	Verify bool
 }
 
+// Filtered16 is an adaptive cdf with 16 bit entries
 type Filtered16 interface {
 	GetModel() []uint16
 	Copy() Filtered16
 	Update(s uint16)
 }
 
+// CDF16Maker makes a Filtered16 for an alphabet of size symbols adapting at rate
 type CDF16Maker func(size, rate int) Filtered16
 
+// Headroom checks that an alphabet of size symbols fits in a cdf of scale, each symbol
+// keeps a count of at least one and the symbol being learned can reach half of the scale
+func Headroom(size, scale int) error {
+	if size < 2 {
+		return fmt.Errorf("alphabet size %d is less than 2", size)
+	}
+	if 2*size > scale {
+		return fmt.Errorf("alphabet size %d is more than half of the scale %d", size, scale)
+	}
+	return nil
+}
+
+// NewCDF16 makes CDF16s, a CDF16 starts uniform and each update moves it towards the cdf
+// that gives every symbol a count of one and the updated symbol the rest of the scale
 func NewCDF16(verify bool) CDF16Maker {
 	return func(size, rate int) Filtered16 {
-		if size != 256 {
-			panic("size is not 256")
+		if err := Headroom(size, CDF16Scale); err != nil {
+			panic(err)
 		}
-		model, sum := make([]uint16, size+1), 0
+		model := make([]uint16, size+1)
 		for i := range model {
-			model[i] = uint16(sum)
-			sum += 32
-		}
-
-		mixin := make([][]uint16, size)
-
-		for i := range mixin {
-			sum, m := 0, make([]uint16, size+1)
-			for j := range m {
-				m[j] = uint16(sum)
-				sum++
-				if j == i {
-					sum += CDF16Scale - size
-				}
-			}
-			mixin[i] = m
+			model[i] = uint16(i * CDF16Scale / size)
 		}
 
 		return &CDF16{
 			Size:   size,
 			Rate:   rate,
 			Model:  model,
-			Mixin:  mixin,
 			Verify: verify,
 		}
 	}
@@ -97,7 +104,6 @@ func (c *CDF16) Copy() Filtered16 {
 		Size:   c.Size,
 		Rate:   c.Rate,
 		Model:  model,
-		Mixin:  c.Mixin,
 		Verify: c.Verify,
 	}
 }
@@ -109,20 +115,15 @@ func (c *CDF16) GetModel() []uint16 {
 
 // Update the cdf
 func (c *CDF16) Update(s uint16) {
-	model, mixin := c.Model, c.Mixin[s]
-	size, rate := len(model)-1, c.Rate
+	model, size, rate := c.Model, len(c.Model)-1, c.Rate
+	if int(s) >= size {
+		panic(fmt.Sprintf("symbol %d is not less than %d", s, size))
+	}
+	// the target cdf has entry i for i <= s and entry i + offset after s, the first
+	// and last entries are already at their targets
+	vector.Blend16(model, int(s), CDF16Scale-size, rate)
 
 	if c.Verify {
-		for i := 1; i < size; i++ {
-			a, b := int(model[i]), int(mixin[i])
-			if a < 0 {
-				panic("a is less than zero")
-			}
-			if b < 0 {
-				panic("b is less than zero")
-			}
-			model[i] = uint16(a + ((b - a) >> rate))
-		}
 		if model[size] != CDF16Scale {
 			panic("cdf scale is incorrect")
 		}
@@ -133,11 +134,6 @@ func (c *CDF16) Update(s uint16) {
 				panic(fmt.Sprintf("invalid cdf %v,%v = %v,%v", i, a, i-1, b))
 			}
 		}
-	} else {
-		for i := 1; i < size; i++ {
-			a, b := int(model[i]), int(mixin[i])
-			model[i] = uint16(a + ((b - a) >> rate))
-		}
 	}
 }
 
@@ -171,21 +167,54 @@ func (h *Histogram) Add(s byte) {
 	h.Index = index
 }
 
+// Touch marks the columns of row i in the workspace that adding s changes
+func (h *Histogram) Touch(w *Workspace, i int, s byte) {
+	if w == nil {
+		return
+	}
+	w.Touch(i, h.Buffer[(h.Index+1)%h.Size])
+	w.Touch(i, s)
+}
+
+// Set sets row i of the workspace
+func (h *Histogram) Set(w *Workspace, i int) {
+	w.SetHistogram(i, &h.Vector)
+}
+
+// Distribution is the normalized histogram
+func (h *Histogram) Distribution() Distribution {
+	return distribution(&h.Vector)
+}
+
+// Copy copies the histogram
+func (h *Histogram) Copy() Counts {
+	c := *h
+	return &c
+}
+
 // Filtered is a filtered counter
 type Filtered struct {
-	Markov  Markov
-	Filters []Filtered16
+	Markov    Markov
+	Filters   []Filtered16
+	Attention Attention
+	Encoding  *Matrix[float32]
+	Workspace *Workspace
 }
 
-// NewFiltered makes a new filtered counter
-func NewFiltered() *Filtered {
+// NewFiltered makes a new filtered counter, the filters are CDF16s unless another
+// counter is given
+func NewFiltered(counter ...Counter) *Filtered {
 	cdf := NewCDF16(false)
+	if len(counter) > 0 {
+		cdf = counter[0].Maker(false)
+	}
 	filters := make([]Filtered16, Size)
 	for i := range filters {
 		filters[i] = cdf(256, i+1)
 	}
 	return &Filtered{
-		Filters: filters,
+		Filters:   filters,
+		Workspace: NewWorkspace(),
 	}
 }
 
@@ -196,50 +225,82 @@ func (f Filtered) Copy() Mix {
 		filters[i] = f.Filters[i].Copy()
 	}
 	return &Filtered{
-		Markov:  f.Markov,
-		Filters: filters,
+		Markov:    f.Markov,
+		Filters:   filters,
+		Attention: f.Attention,
+		Encoding:  f.Encoding,
+		Workspace: NewWorkspace(),
 	}
 }
 
 // Add adds a symbol to a filter
-func (f Filtered) Add(s byte) {
+func (f *Filtered) Add(s byte) {
 	for i := range f.Filters {
 		f.Filters[i].Update(uint16(s))
 	}
+	if f.Workspace != nil {
+		f.Workspace.Invalidate()
+	}
 	for k := Order; k > 0; k-- {
 		f.Markov[k] = f.Markov[k-1]
 	}
 	f.Markov[0] = s
 }
 
+// workspace sets the rows of the workspace
+func (f Filtered) workspace() *Workspace {
+	w := f.Workspace
+	if w == nil {
+		w = NewWorkspace()
+	}
+	for i := range f.Filters {
+		w.SetCDF(i, f.Filters[i].GetModel())
+	}
+	w.SetMarkov(f.Markov)
+	return w
+}
+
+// Input is the matrix of the filter and markov rows without the encoding, it is owned by
+// the workspace and valid until the next Add
+func (f Filtered) Input() Matrix[float32] {
+	return f.workspace().Input
+}
+
 // Mix mixes the filters outputting a matrix
 func (f Filtered) Mix() [InputSize]float32 {
-	x := NewMatrix(256, Size+Order+1)
+	w := f.workspace()
+	if f.Encoding == nil && f.Attention == nil {
+		return w.SelfAttention()
+	}
+	x := w.Input
+	if f.Encoding != nil {
+		x = x.Add(*f.Encoding)
+	}
+	if f.Attention != nil {
+		return f.Attention.Attend(x)
+	}
+	return SelfAttention(x)
+}
+
+// Predict outputs the distribution of each filter
+func (f Filtered) Predict() []Distribution {
+	d := make([]Distribution, len(f.Filters))
 	for i := range f.Filters {
 		model := f.Filters[i].GetModel()
-		last, sum := uint16(0), float32(0.0)
-		for _, v := range model[1:] {
-			sum += float32(v - last)
-			last = v
-		}
-		last = 0
-		for _, v := range model[1:] {
-			x.Data = append(x.Data, float32(v-last)/sum)
+		last, sum := uint16(0), float64(model[len(model)-1]-model[0])
+		for j, v := range model[1:] {
+			d[i][j] = float64(v-last) / sum
 			last = v
 		}
 	}
-	for _, v := range f.Markov {
-		d := make([]float32, 256)
-		d[v] = 1
-		x.Data = append(x.Data, d...)
-	}
-	return SelfAttention(x)
+	return d
 }
 
 // Filtered is a filtered counter
 type CrossFiltered struct {
-	Markov  [2]Markov
-	Filters [2][]Filtered16
+	Markov     [2]Markov
+	Filters    [2][]Filtered16
+	Workspaces [2]*Workspace
 }
 
 // NewCrossFiltered makes a new cross filtered counter
@@ -249,11 +310,12 @@ func NewCrossFiltered() *CrossFiltered {
 	for i := range filters {
 		filters[i] = make([]Filtered16, Size)
 		for j := range filters[i] {
-			filters[i][j] = cdf(256, i+1)
+			filters[i][j] = cdf(256, j+1)
 		}
 	}
 	return &CrossFiltered{
-		Filters: filters,
+		Filters:    filters,
+		Workspaces: [2]*Workspace{NewWorkspace(), NewWorkspace()},
 	}
 }
 
@@ -261,19 +323,20 @@ func NewCrossFiltered() *CrossFiltered {
 func (f CrossFiltered) Copy() CrossMix {
 	filters := [2][]Filtered16{}
 	for i := range filters {
-		filters[i] = make([]Filtered16, len(f.Filters))
+		filters[i] = make([]Filtered16, len(f.Filters[i]))
 		for j := range filters[i] {
 			filters[i][j] = f.Filters[i][j].Copy()
 		}
 	}
 	return &CrossFiltered{
-		Markov:  f.Markov,
-		Filters: filters,
+		Markov:     f.Markov,
+		Filters:    filters,
+		Workspaces: [2]*Workspace{NewWorkspace(), NewWorkspace()},
 	}
 }
 
 // Add adds a symbol to a filter
-func (f CrossFiltered) Add(s1, s2 byte) {
+func (f *CrossFiltered) Add(s1, s2 byte) {
 	for i := range f.Filters[0] {
 		f.Filters[0][i].Update(uint16(s1))
 	}
@@ -288,70 +351,70 @@ func (f CrossFiltered) Add(s1, s2 byte) {
 		f.Markov[1][k] = f.Markov[1][k-1]
 	}
 	f.Markov[1][0] = s2
+	for _, w := range f.Workspaces {
+		if w != nil {
+			w.Invalidate()
+		}
+	}
 }
 
 // Mix mixes the filters outputting a matrix
 func (f CrossFiltered) Mix() [InputSize]float32 {
-	x := [2]Matrix{NewMatrix(256, Size+Order+1), NewMatrix(256, Size+Order+1)}
-	for i := range x {
-		for j := range f.Filters[i] {
-			model := f.Filters[i][j].GetModel()
-			last, sum := uint16(0), float32(0.0)
-			for _, v := range model[1:] {
-				sum += float32(v - last)
-				last = v
-			}
-			last = 0
-			for _, v := range model[1:] {
-				x[i].Data = append(x[i].Data, float32(v-last)/sum)
-				last = v
-			}
+	w := f.Workspaces
+	for i := range w {
+		if w[i] == nil {
+			w[i] = NewWorkspace()
 		}
-		for _, v := range f.Markov[i] {
-			d := make([]float32, 256)
-			d[v] = 1
-			x[i].Data = append(x[i].Data, d...)
+		for j := range f.Filters[i] {
+			w[i].SetCDF(j, f.Filters[i][j].GetModel())
 		}
+		w[i].SetMarkov(f.Markov[i])
 	}
-	return CrossSelfAttention(x[0], x[1])
+	return w[0].CrossSelfAttention(w[1])
 }
 
 // Mixer mixes several histograms together
 type Mixer struct {
 	Markov     Markov
-	Histograms []Histogram
-}
-
-// NewMixer makes a new mixer
-func NewMixer() *Mixer {
-	histograms := make([]Histogram, Size)
-	histograms[0] = NewHistogram(1)
-	histograms[1] = NewHistogram(2)
-	histograms[2] = NewHistogram(4)
-	histograms[3] = NewHistogram(8)
-	histograms[4] = NewHistogram(16)
-	histograms[5] = NewHistogram(32)
-	histograms[6] = NewHistogram(64)
-	histograms[7] = NewHistogram(128)
+	Histograms []Counts
+	Workspace  *Workspace
+}
+
+// NewMixer makes a new mixer, the histograms are windows of 1 to 128 symbols unless up
+// to Size histograms are given
+func NewMixer(histograms ...Counts) *Mixer {
+	if len(histograms) > Size {
+		panic(fmt.Errorf("%d histograms, expected at most %d", len(histograms), Size))
+	}
+	if len(histograms) == 0 {
+		histograms = make([]Counts, Size)
+		for i := range histograms {
+			h := NewHistogram(1 << i)
+			histograms[i] = &h
+		}
+	}
 	return &Mixer{
 		Histograms: histograms,
+		Workspace:  NewWorkspace(),
 	}
 }
 
 func (m Mixer) Copy() Mix {
-	histograms := make([]Histogram, Size)
+	histograms := make([]Counts, len(m.Histograms))
 	for i := range m.Histograms {
-		histograms[i] = m.Histograms[i]
+		histograms[i] = m.Histograms[i].Copy()
 	}
 	return &Mixer{
 		Markov:     m.Markov,
 		Histograms: histograms,
+		Workspace:  NewWorkspace(),
 	}
 }
 
 // Add adds a symbol to a mixer
 func (m *Mixer) Add(s byte) {
 	for i := range m.Histograms {
+		m.Histograms[i].Touch(m.Workspace, i, s)
 		m.Histograms[i].Add(s)
 	}
 	for k := Order; k > 0; k-- {
@@ -362,28 +425,31 @@ func (m *Mixer) Add(s byte) {
 
 // Mix mixes the histograms outputting a matrix
 func (m Mixer) Mix() [InputSize]float32 {
-	x := NewMatrix(256, Size+Order+1)
+	w := m.Workspace
+	if w == nil {
+		w = NewWorkspace()
+	}
 	for i := range m.Histograms {
-		sum := float32(0.0)
-		for _, v := range m.Histograms[i].Vector {
-			sum += float32(v)
-		}
-		for _, v := range m.Histograms[i].Vector {
-			x.Data = append(x.Data, float32(v)/sum)
-		}
+		m.Histograms[i].Set(w, i)
 	}
-	for _, v := range m.Markov {
-		d := make([]float32, 256)
-		d[v] = 1
-		x.Data = append(x.Data, d...)
+	w.SetMarkov(m.Markov)
+	return w.SelfAttention()
+}
+
+// Predict outputs the distribution of each histogram
+func (m Mixer) Predict() []Distribution {
+	d := make([]Distribution, len(m.Histograms))
+	for i := range m.Histograms {
+		d[i] = m.Histograms[i].Distribution()
 	}
-	return SelfAttention(x)
+	return d
 }
 
 // CrossMixer mixes several histograms together
 type CrossMixer struct {
 	Markov     [2]Markov
 	Histograms [2][]Histogram
+	Workspaces [2]*Workspace
 }
 
 // NewCrossMixer makes a new cross mixer
@@ -402,6 +468,7 @@ func NewCrossMixer() *CrossMixer {
 	}
 	return &CrossMixer{
 		Histograms: histograms,
+		Workspaces: [2]*Workspace{NewWorkspace(), NewWorkspace()},
 	}
 }
 
@@ -417,12 +484,14 @@ func (m CrossMixer) Copy() CrossMix {
 	return &CrossMixer{
 		Markov:     m.Markov,
 		Histograms: histograms,
+		Workspaces: [2]*Workspace{NewWorkspace(), NewWorkspace()},
 	}
 }
 
 // Add adds a symbol to a cross mixer
 func (m *CrossMixer) Add(s1, s2 byte) {
 	for i := range m.Histograms[0] {
+		m.Histograms[0][i].Touch(m.Workspaces[0], i, s1)
 		m.Histograms[0][i].Add(s1)
 	}
 	for k := Order; k > 0; k-- {
@@ -430,6 +499,7 @@ func (m *CrossMixer) Add(s1, s2 byte) {
 	}
 	m.Markov[0][0] = s1
 	for i := range m.Histograms[1] {
+		m.Histograms[1][i].Touch(m.Workspaces[1], i, s2)
 		m.Histograms[1][i].Add(s2)
 	}
 	for k := Order; k > 0; k-- {
@@ -440,22 +510,15 @@ func (m *CrossMixer) Add(s1, s2 byte) {
 
 // Mix mixes the histograms outputting a matrix
 func (m CrossMixer) Mix() [InputSize]float32 {
-	x := [2]Matrix{NewMatrix(256, Size+Order+1), NewMatrix(256, Size+Order+1)}
-	for i := range x {
-		for j := range m.Histograms[i] {
-			sum := float32(0.0)
-			for _, v := range m.Histograms[i][j].Vector {
-				sum += float32(v)
-			}
-			for _, v := range m.Histograms[i][j].Vector {
-				x[i].Data = append(x[i].Data, float32(v)/sum)
-			}
+	w := m.Workspaces
+	for i := range w {
+		if w[i] == nil {
+			w[i] = NewWorkspace()
 		}
-		for _, v := range m.Markov[i] {
-			d := make([]float32, 256)
-			d[v] = 1
-			x[i].Data = append(x[i].Data, d...)
+		for j := range m.Histograms[i] {
+			w[i].SetHistogram(j, &m.Histograms[i][j].Vector)
 		}
+		w[i].SetMarkov(m.Markov[i])
 	}
-	return CrossSelfAttention(x[0], x[1])
+	return w[0].CrossSelfAttention(w[1])
 }
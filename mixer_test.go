@@ -77,3 +77,81 @@ func TestCrossFiltered(t *testing.T) {
 		t.Fatalf("%f < %f", j, i)
 	}
 }
+
+func TestCDFTable(t *testing.T) {
+	// the cdf update used to blend with a table of target cdfs
+	mixin := make([][]uint16, 256)
+	for i := range mixin {
+		sum, m := 0, make([]uint16, 257)
+		for j := range m {
+			m[j] = uint16(sum)
+			sum++
+			if j == i {
+				sum += CDF16Scale - 256
+			}
+		}
+		mixin[i] = m
+	}
+	rng := rand.New(rand.NewSource(1))
+	for rate := 1; rate < 9; rate++ {
+		filtered := NewCDF16(true)(256, rate)
+		model := make([]uint16, 257)
+		for i := range model {
+			model[i] = uint16(32 * i)
+		}
+		for j := 0; j < 1024; j++ {
+			s := rng.Intn(256)
+			filtered.Update(uint16(s))
+			for i := 1; i < 256; i++ {
+				a, b := int(model[i]), int(mixin[s][i])
+				model[i] = uint16(a + ((b - a) >> rate))
+			}
+			for i, v := range filtered.GetModel() {
+				if v != model[i] {
+					t.Fatalf("%d != %d", v, model[i])
+				}
+			}
+		}
+	}
+}
+
+func TestCDFSizes(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, size := range []int{2, 3, 16, 300, CDF16Scale / 2} {
+		for _, rate := range []int{1, 5, 8} {
+			filtered := NewCDF16(true)(size, rate)
+			for j := 0; j < 256; j++ {
+				filtered.Update(uint16(rng.Intn(size)))
+			}
+		}
+	}
+	for _, size := range []int{2, 1000, 1 << 16} {
+		filtered := NewCDF32(true)(size, 10)
+		for j := 0; j < 16; j++ {
+			s := uint32(rng.Intn(size))
+			filtered.Update(s)
+			model := filtered.GetModel()
+			if p := model[s+1] - model[s]; p < CDF32Scale/(2*uint32(size)) {
+				t.Fatalf("symbol %d has count %d after an update", s, p)
+			}
+		}
+		data, err := filtered.(*CDF32).MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		restored := &CDF32{}
+		if err := restored.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		for i, v := range filtered.GetModel() {
+			if v != restored.Model[i] {
+				t.Fatalf("%d != %d", v, restored.Model[i])
+			}
+		}
+	}
+	for _, size := range []int{0, 1, CDF16Scale/2 + 1} {
+		if Headroom(size, CDF16Scale) == nil {
+			t.Fatalf("expected size %d to not fit", size)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math"
+)
+
+// Model is a next symbol model combining the mixers, the slot table, the suffix array
+// and optionally a logistic mixer over all of their predictions
+type Model struct {
+	Transforms *[Transforms][256]float32
+	Table      *SymbolTable
+	Suffix     *Suffix
+	Mixers     []Mix
+	Logistic   *Logistic
+	Context    []byte
+	Predicted  bool
+}
+
+// NewModel makes a new model, the first mixer keys the slot table
+func NewModel(transforms *[Transforms][256]float32, table *SymbolTable, suffix *Suffix, logistic bool) *Model {
+	m := &Model{
+		Transforms: transforms,
+		Table:      table,
+		Suffix:     suffix,
+		Mixers:     []Mix{NewFiltered(), NewMixer()},
+	}
+	for _, mixer := range m.Mixers {
+		mixer.Add(0)
+	}
+	if logistic {
+		inputs := 1
+		if suffix != nil {
+			inputs++
+		}
+		for _, mixer := range m.Mixers {
+			if predictor, ok := mixer.(Predictor); ok {
+				inputs += len(predictor.Predict())
+			}
+		}
+		m.Logistic = NewLogistic(inputs, 256, LogisticRate)
+	}
+	return m
+}
+
+// UseCounter replaces the filtered mixer that keys the slot table with one using counter,
+// the counter has to be the one the slot table was trained with
+func (m *Model) UseCounter(counter Counter) {
+	f := NewFiltered(counter)
+	f.Add(0)
+	for _, s := range m.Context {
+		f.Add(s)
+	}
+	m.Mixers[0] = f
+}
+
+// Copy copies the model, the table and suffix array are shared
+func (m *Model) Copy() *Model {
+	mixers := make([]Mix, len(m.Mixers))
+	for i := range mixers {
+		mixers[i] = m.Mixers[i].Copy()
+	}
+	context := make([]byte, len(m.Context))
+	copy(context, m.Context)
+	n := &Model{
+		Transforms: m.Transforms,
+		Table:      m.Table,
+		Suffix:     m.Suffix,
+		Mixers:     mixers,
+		Context:    context,
+		Predicted:  m.Predicted,
+	}
+	if m.Logistic != nil {
+		n.Logistic = m.Logistic.Copy()
+	}
+	return n
+}
+
+// Histogram looks up the next symbol histogram in the slot table
+func (m *Model) Histogram() (histogram [256]uint) {
+	if m.Table == nil {
+		return histogram
+	}
+	vv := m.Mixers[0].Mix()
+	keys := Keys(m.Transforms, &vv)
+	return m.Table.Search(&keys)
+}
+
+// Lookup looks up the next symbol distribution in the slot table, a bit table is searched
+// node by node
+func (m *Model) Lookup() Distribution {
+	if m.Table == nil || !m.Table.Bits {
+		return NewDistribution(m.Histogram())
+	}
+	vv := m.Mixers[0].Mix()
+	keys := Keys(m.Transforms, &vv)
+	return m.Table.SearchBits(&keys)
+}
+
+// Predict predicts the distribution of the next symbol
+func (m *Model) Predict() Distribution {
+	table := m.Lookup()
+	length, next := 0, [256]uint{}
+	if m.Suffix != nil {
+		length, next = m.Suffix.Match(m.Context)
+	}
+	m.Predicted = true
+	if m.Logistic == nil {
+		d := Interpolate(table, length, next)
+		d.Smooth(Epsilon)
+		return d
+	}
+	inputs := []Distribution{table}
+	if m.Suffix != nil {
+		inputs = append(inputs, NewDistribution(next))
+	}
+	for _, mixer := range m.Mixers {
+		if predictor, ok := mixer.(Predictor); ok {
+			inputs = append(inputs, predictor.Predict()...)
+		}
+	}
+	context := 0
+	if len(m.Context) > 0 {
+		context = int(m.Context[len(m.Context)-1])
+	}
+	return m.Logistic.Mix(inputs, context)
+}
+
+// Add adds a symbol to the model, training the logistic mixer on the last prediction
+func (m *Model) Add(s byte) {
+	if m.Logistic != nil && m.Predicted {
+		m.Logistic.Update(s)
+	}
+	m.Predicted = false
+	for _, mixer := range m.Mixers {
+		mixer.Add(s)
+	}
+	m.Context = append(m.Context, s)
+	if len(m.Context) > 2*MaxMatch {
+		m.Context = append(m.Context[:0], m.Context[len(m.Context)-MaxMatch:]...)
+	}
+}
+
+// Cost is the number of bits needed to code s with the distribution
+func (d *Distribution) Cost(s byte) float64 {
+	return -math.Log2(d[s])
+}
+
+// Smooth mixes a uniform distribution into the distribution so no symbol has zero probability
+func (d *Distribution) Smooth(epsilon float64) {
+	sum := 0.0
+	for _, v := range d {
+		sum += v
+	}
+	if sum == 0 {
+		for i := range d {
+			d[i] = 1.0 / 256
+		}
+		return
+	}
+	for i, v := range d {
+		d[i] = (1-epsilon)*v/sum + epsilon/256
+	}
+}
+
+// Evaluate computes the bits per byte of the model on data
+func Evaluate(m *Model, data []byte) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+	bits := 0.0
+	for _, s := range data {
+		d := m.Predict()
+		bits += d.Cost(s)
+		m.Add(s)
+	}
+	return bits / float64(len(data))
+}
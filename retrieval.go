@@ -0,0 +1,185 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"sort"
+)
+
+const (
+	// OffsetBits is the number of index bits in the offset table
+	OffsetBits = 26
+	// CopyLength is the maximum number of bytes copied from a retrieved passage
+	CopyLength = 8
+)
+
+// Offsets is a slot table that holds corpus offsets instead of symbols
+type Offsets struct {
+	Bits  uint
+	Slots []uint32
+}
+
+// NewOffsets makes a new offset table with 2^bits slots
+func NewOffsets(bits uint) *Offsets {
+	return &Offsets{
+		Bits:  bits,
+		Slots: make([]uint32, 1<<bits),
+	}
+}
+
+// Index maps a key to a slot index
+func (o *Offsets) Index(key uint32) uint32 {
+	return key & uint32(len(o.Slots)-1)
+}
+
+// Set stores the corpus offset of the next symbol for the keys
+func (o *Offsets) Set(keys *[Transforms]uint32, offset int) {
+	for _, key := range keys {
+		o.Slots[o.Index(key)] = uint32(offset + 1)
+	}
+}
+
+// Write writes the offset table followed by the table info with the current version
+func (o *Offsets) Write(w io.Writer, info TableInfo) error {
+	info.Version = TableVersion
+	buffer := bufio.NewWriter(w)
+	err := binary.Write(buffer, binary.LittleEndian, o.Slots)
+	if err != nil {
+		return err
+	}
+	err = binary.Write(buffer, binary.LittleEndian, info)
+	if err != nil {
+		return err
+	}
+	return buffer.Flush()
+}
+
+// OffsetTable is a file backed offset table
+type OffsetTable struct {
+	Slots   int64
+	Table   io.ReaderAt
+	Counter Counter
+}
+
+// OpenOffsets opens an offset table
+func OpenOffsets(file *os.File) (*OffsetTable, error) {
+	slots, info, err := openTable(file, 4)
+	if err != nil {
+		return nil, err
+	}
+	return &OffsetTable{
+		Slots:   slots,
+		Table:   file,
+		Counter: Counter(info.Counter),
+	}, nil
+}
+
+// Search searches the offset table for the keys, widening the window until an offset is found
+// The returned map counts how many keys voted for each corpus offset
+func (o *OffsetTable) Search(keys *[Transforms]uint32) map[uint32]uint {
+	votes := make(map[uint32]uint)
+	for i := int64(1); i < Backoff && len(votes) == 0; i *= 2 {
+		for _, key := range keys {
+			index := int64(key) & (o.Slots - 1)
+			buffer := Window(o.Table, o.Slots, index, i, 4)
+			for j := 0; j+4 <= len(buffer); j += 4 {
+				if offset := binary.LittleEndian.Uint32(buffer[j:]); offset != 0 {
+					votes[offset-1]++
+				}
+			}
+		}
+	}
+	return votes
+}
+
+// Span is a generated span copied from the corpus
+type Span struct {
+	Offset int
+	Text   []byte
+}
+
+// Source describes where in the corpus a span came from
+func (s Span) Source(corpus []byte) string {
+	line := bytes.Count(corpus[:s.Offset], []byte{'\n'}) + 1
+	begin := bytes.LastIndexByte(corpus[:s.Offset], '\n') + 1
+	end := bytes.IndexByte(corpus[s.Offset:], '\n')
+	if end < 0 {
+		end = len(corpus)
+	} else {
+		end += s.Offset
+	}
+	return fmt.Sprintf("offset %d line %d: %q", s.Offset, line, bytes.TrimSpace(corpus[begin:end]))
+}
+
+// Pick samples a corpus offset proportional to its votes
+func Pick(rng *rand.Rand, votes map[uint32]uint) uint32 {
+	offsets, sum := make([]uint32, 0, len(votes)), uint(0)
+	for offset, count := range votes {
+		offsets = append(offsets, offset)
+		sum += count
+	}
+	sort.Slice(offsets, func(i, j int) bool {
+		return offsets[i] < offsets[j]
+	})
+	total, selected := uint(0), uint(rng.Int63n(int64(sum)))
+	for _, offset := range offsets {
+		total += votes[offset]
+		if selected < total {
+			return offset
+		}
+	}
+	return offsets[len(offsets)-1]
+}
+
+// Retrieve generates text by copying passages from the corpus with the offset table, the
+// mixer is primed like the one of inference with the counter the table was trained with
+func Retrieve(name string, corpus []byte, input string, length int) []Span {
+	rng := rand.New(rand.NewSource(1))
+	db, err := os.Open(name)
+	if err != nil {
+		panic(err)
+	}
+	defer db.Close()
+	table, err := OpenOffsets(db)
+	if err != nil {
+		panic(err)
+	}
+	transforms := GetTransforms()
+	m := NewModel(&transforms, nil, nil, false)
+	m.UseCounter(table.Counter)
+	for _, v := range []byte(input) {
+		m.Add(v)
+	}
+	spans, generated := []Span{}, 0
+	for generated < length {
+		vv := m.Mixers[0].Mix()
+		keys := Keys(&transforms, &vv)
+		votes := table.Search(&keys)
+		if len(votes) == 0 {
+			break
+		}
+		offset := int(Pick(rng, votes))
+		span := Span{
+			Offset: offset,
+		}
+		for i := offset; i < len(corpus) && i < offset+CopyLength && generated < length; i++ {
+			span.Text = append(span.Text, corpus[i])
+			m.Add(corpus[i])
+			generated++
+		}
+		if len(span.Text) == 0 {
+			break
+		}
+		spans = append(spans, span)
+	}
+	return spans
+}
@@ -0,0 +1,98 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestOffsets(t *testing.T) {
+	corpus := []byte("to be or not to be")
+	offsets := NewOffsets(16)
+	transforms := GetTransforms()
+	m := NewFiltered()
+	m.Add(0)
+	keys := make([][Transforms]uint32, len(corpus))
+	for j, v := range corpus {
+		vv := m.Mix()
+		keys[j] = Keys(&transforms, &vv)
+		offsets.Set(&keys[j], j)
+		m.Add(v)
+	}
+
+	name := filepath.Join(t.TempDir(), "offsets.bin")
+	out, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = offsets.Write(out, TableInfo{Counter: uint32(CountAdaptive)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out.Close()
+
+	in, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer in.Close()
+	table, err := OpenOffsets(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.Slots != 1<<16 || table.Counter != CountAdaptive {
+		t.Fatalf("%d slots and counter %s", table.Slots, table.Counter)
+	}
+	last := len(corpus) - 1
+	votes := table.Search(&keys[last])
+	if votes[uint32(last)] == 0 {
+		t.Fatalf("offset %d not retrieved: %v", last, votes)
+	}
+	for offset := range votes {
+		if int(offset) >= len(corpus) {
+			t.Fatalf("offset %d out of range", offset)
+		}
+	}
+}
+
+func TestSpanSource(t *testing.T) {
+	corpus := []byte("first line\nsecond line\n")
+	span := Span{Offset: 14, Text: []byte("ond")}
+	if source := span.Source(corpus); source != `offset 14 line 2: "second line"` {
+		t.Fatal(source)
+	}
+}
+
+func TestRetrieve(t *testing.T) {
+	corpus := []byte(strings.Repeat("the quick brown fox jumps over the lazy dog. ", 8))
+	offsets := NewOffsets(20)
+	Train(NewFiltered(DualRate), corpus, NewSymbols(16), offsets, false)
+	name := filepath.Join(t.TempDir(), "offsets.bin")
+	out, err := os.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = offsets.Write(out, TableInfo{Counter: uint32(DualRate)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	out.Close()
+
+	spans := Retrieve(name, corpus, "the quick brown", 32)
+	text := []byte{}
+	for _, span := range spans {
+		if !bytes.Equal(corpus[span.Offset:span.Offset+len(span.Text)], span.Text) {
+			t.Fatalf("%q is not at offset %d", span.Text, span.Offset)
+		}
+		text = append(text, span.Text...)
+	}
+	if len(text) != 32 {
+		t.Fatalf("%d bytes retrieved", len(text))
+	}
+}
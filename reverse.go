@@ -0,0 +1,88 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ReverseName is the name of the right to left model stored alongside the named model
+func ReverseName(name string) string {
+	ext := filepath.Ext(name)
+	return strings.TrimSuffix(name, ext) + ".reverse" + ext
+}
+
+// OffsetsName is the name of the offset table stored alongside the named model
+func OffsetsName(name string) string {
+	ext := filepath.Ext(name)
+	return strings.TrimSuffix(name, ext) + ".offsets" + ext
+}
+
+// Reverse returns a reversed copy of data
+func Reverse(data []byte) []byte {
+	reversed := make([]byte, len(data))
+	for i, v := range data {
+		reversed[len(data)-1-i] = v
+	}
+	return reversed
+}
+
+// BiScored is text scored by a left to right and a right to left model
+// LogProbs is the mean of the forward and backward log probabilities of each byte
+type BiScored struct {
+	Text        string    `json:"text"`
+	Forward     []float64 `json:"forward"`
+	Backward    []float64 `json:"backward"`
+	LogProbs    []float64 `json:"logprobs"`
+	Total       float64   `json:"total"`
+	BitsPerByte float64   `json:"bits_per_byte"`
+	Anomaly     int       `json:"anomaly"`
+}
+
+// Bidirectional combines a left to right and a right to left model
+type Bidirectional struct {
+	Forward  *Model
+	Backward *Model
+}
+
+// Score scores text in both directions, the backward log probability of a byte is
+// conditioned on the bytes after it. Anomaly is the position with the lowest combined log probability
+func (b Bidirectional) Score(text []byte) BiScored {
+	forward, backward := Score(b.Forward, text), Score(b.Backward, Reverse(text))
+	scored := BiScored{
+		Text:     string(text),
+		Forward:  forward.LogProbs,
+		Backward: make([]float64, len(text)),
+		LogProbs: make([]float64, len(text)),
+		Total:    (forward.Total + backward.Total) / 2,
+		Anomaly:  -1,
+	}
+	for i := range text {
+		scored.Backward[i] = backward.LogProbs[len(text)-1-i]
+		scored.LogProbs[i] = (scored.Forward[i] + scored.Backward[i]) / 2
+		if scored.Anomaly < 0 || scored.LogProbs[i] < scored.LogProbs[scored.Anomaly] {
+			scored.Anomaly = i
+		}
+	}
+	if len(text) > 0 {
+		scored.BitsPerByte = -scored.Total / math.Ln2 / float64(len(text))
+	}
+	return scored
+}
+
+// Rank scores the candidates in both directions and sorts them from lowest to highest bits per byte
+func (b Bidirectional) Rank(candidates [][]byte) []BiScored {
+	ranked := make([]BiScored, len(candidates))
+	for i, candidate := range candidates {
+		ranked[i] = b.Score(candidate)
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].BitsPerByte < ranked[j].BitsPerByte
+	})
+	return ranked
+}
@@ -0,0 +1,47 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestReverseName(t *testing.T) {
+	if name := ReverseName("model.bin"); name != "model.reverse.bin" {
+		t.Fatal(name)
+	}
+	if name := ReverseName("dir/suffix"); name != "dir/suffix.reverse" {
+		t.Fatal(name)
+	}
+	if name := OffsetsName("model.bin"); name != "model.offsets.bin" {
+		t.Fatal(name)
+	}
+}
+
+func TestBidirectional(t *testing.T) {
+	corpus := []byte("the cat sat on the mat. the dog sat on the log. ")
+	transforms := GetTransforms()
+	b := Bidirectional{
+		Forward:  NewModel(&transforms, nil, NewSuffix(corpus), false),
+		Backward: NewModel(&transforms, nil, NewSuffix(Reverse(corpus)), false),
+	}
+	scored := b.Score([]byte("the dog sat on the mqt."))
+	if scored.Anomaly != 20 {
+		t.Fatalf("%d != 20 %v", scored.Anomaly, scored.LogProbs)
+	}
+	forward, backward := 0.0, 0.0
+	for i := range scored.LogProbs {
+		forward += scored.Forward[i]
+		backward += scored.Backward[i]
+	}
+	if math.Abs((forward+backward)/2-scored.Total) > 1e-9 {
+		t.Fatalf("%f != %f", (forward+backward)/2, scored.Total)
+	}
+	ranked := b.Rank([][]byte{[]byte("the mat sat on the cat"), []byte("xqz jvk")})
+	if ranked[0].Text != "the mat sat on the cat" {
+		t.Fatalf("%q", ranked[0].Text)
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"math"
+	"sort"
+)
+
+// Scored is text scored by the model
+type Scored struct {
+	Text        string    `json:"text"`
+	LogProbs    []float64 `json:"logprobs"`
+	Total       float64   `json:"total"`
+	BitsPerByte float64   `json:"bits_per_byte"`
+}
+
+// Score feeds text through a copy of the model and returns the natural log probability of each byte
+func Score(m *Model, text []byte) Scored {
+	m = m.Copy()
+	scored := Scored{
+		Text:     string(text),
+		LogProbs: make([]float64, len(text)),
+	}
+	for i, s := range text {
+		d := m.Predict()
+		scored.LogProbs[i] = math.Log(d[s])
+		scored.Total += scored.LogProbs[i]
+		m.Add(s)
+	}
+	if len(text) > 0 {
+		scored.BitsPerByte = -scored.Total / math.Ln2 / float64(len(text))
+	}
+	return scored
+}
+
+// Rank scores the candidates and sorts them from lowest to highest bits per byte
+func Rank(m *Model, candidates [][]byte) []Scored {
+	ranked := make([]Scored, len(candidates))
+	for i, candidate := range candidates {
+		ranked[i] = Score(m, candidate)
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].BitsPerByte < ranked[j].BitsPerByte
+	})
+	return ranked
+}
+
+// Candidate is a JSONL candidate
+type Candidate struct {
+	Text string `json:"text"`
+}
+
+// ReadCandidates reads one candidate per line, lines starting with { are JSON objects with a text field
+func ReadCandidates(r io.Reader) ([][]byte, error) {
+	candidates := [][]byte{}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if bytes.HasPrefix(bytes.TrimSpace(line), []byte("{")) {
+			var candidate Candidate
+			err := json.Unmarshal(line, &candidate)
+			if err != nil {
+				return nil, err
+			}
+			candidates = append(candidates, []byte(candidate.Text))
+			continue
+		}
+		candidate := make([]byte, len(line))
+		copy(candidate, line)
+		candidates = append(candidates, candidate)
+	}
+	return candidates, scanner.Err()
+}
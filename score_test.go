@@ -0,0 +1,45 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestScore(t *testing.T) {
+	transforms := GetTransforms()
+	m := NewModel(&transforms, nil, NewSuffix([]byte("to be or not to be, that is the question")), false)
+	scored := Score(m, []byte("to be"))
+	if len(scored.LogProbs) != 5 {
+		t.Fatalf("%d != 5", len(scored.LogProbs))
+	}
+	total := 0.0
+	for _, v := range scored.LogProbs {
+		total += v
+	}
+	if math.Abs(total-scored.Total) > 1e-9 {
+		t.Fatalf("%f != %f", total, scored.Total)
+	}
+	if len(m.Context) != 0 {
+		t.Fatal("scoring modified the model")
+	}
+
+	ranked := Rank(m, [][]byte{[]byte("xq zj kv"), []byte("not to be")})
+	if ranked[0].Text != "not to be" {
+		t.Fatalf("%q", ranked[0].Text)
+	}
+}
+
+func TestReadCandidates(t *testing.T) {
+	candidates, err := ReadCandidates(strings.NewReader("plain text\n{\"text\": \"json\\ttext\"}\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candidates) != 2 || string(candidates[0]) != "plain text" || string(candidates[1]) != "json\ttext" {
+		t.Fatalf("%q", candidates)
+	}
+}
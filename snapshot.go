@@ -0,0 +1,1036 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+)
+
+// SnapshotVersion is the version of the mixer snapshot format
+const SnapshotVersion = 1
+
+const (
+	// FilteredSnapshot is the snapshot kind of a Filtered
+	FilteredSnapshot = iota + 1
+	// MixerSnapshot is the snapshot kind of a Mixer
+	MixerSnapshot
+	// CrossFilteredSnapshot is the snapshot kind of a CrossFiltered
+	CrossFilteredSnapshot
+	// CrossMixerSnapshot is the snapshot kind of a CrossMixer
+	CrossMixerSnapshot
+	// CDF16Snapshot is the snapshot kind of a CDF16
+	CDF16Snapshot
+	// CDF32Snapshot is the snapshot kind of a CDF32
+	CDF32Snapshot
+	// DualRate16Snapshot is the snapshot kind of a DualRate16
+	DualRate16Snapshot
+	// Adaptive16Snapshot is the snapshot kind of an Adaptive16
+	Adaptive16Snapshot
+	// History16Snapshot is the snapshot kind of a History16
+	History16Snapshot
+	// HistogramSnapshot is the snapshot kind of a Histogram
+	HistogramSnapshot
+	// RingSnapshot is the snapshot kind of a Ring
+	RingSnapshot
+	// DecaySnapshot is the snapshot kind of a Decay
+	DecaySnapshot
+)
+
+// ErrTrailingData is returned when a snapshot has data after the end of the state
+var ErrTrailingData = errors.New("snapshot has trailing data")
+
+// writeHeader writes the snapshot version and kind
+func writeHeader(buffer *bytes.Buffer, kind uint32) error {
+	return binary.Write(buffer, binary.LittleEndian, [2]uint32{SnapshotVersion, kind})
+}
+
+// readHeader reads the snapshot version and kind and checks them
+func readHeader(reader *bytes.Reader, kind uint32) error {
+	var header [2]uint32
+	err := binary.Read(reader, binary.LittleEndian, &header)
+	if err != nil {
+		return err
+	}
+	if header[0] != SnapshotVersion {
+		return fmt.Errorf("snapshot version is %d, expected %d", header[0], SnapshotVersion)
+	}
+	if header[1] != kind {
+		return fmt.Errorf("snapshot kind is %d, expected %d", header[1], kind)
+	}
+	return nil
+}
+
+// readEnd checks that all of the snapshot has been read
+func readEnd(reader *bytes.Reader) error {
+	if reader.Len() != 0 {
+		return ErrTrailingData
+	}
+	return nil
+}
+
+// writeBlock writes a length prefixed block
+func writeBlock(buffer *bytes.Buffer, data []byte) error {
+	err := binary.Write(buffer, binary.LittleEndian, uint32(len(data)))
+	if err != nil {
+		return err
+	}
+	_, err = buffer.Write(data)
+	return err
+}
+
+// readBlock reads a length prefixed block
+func readBlock(reader *bytes.Reader) ([]byte, error) {
+	var length uint32
+	err := binary.Read(reader, binary.LittleEndian, &length)
+	if err != nil {
+		return nil, err
+	}
+	if int64(length) > int64(reader.Len()) {
+		return nil, fmt.Errorf("block is %d bytes, only %d remain", length, reader.Len())
+	}
+	data := make([]byte, length)
+	_, err = reader.Read(data)
+	return data, err
+}
+
+// MarshalBinary encodes the cdf
+func (c *CDF16) MarshalBinary() ([]byte, error) {
+	buffer := bytes.Buffer{}
+	err := writeHeader(&buffer, CDF16Snapshot)
+	if err != nil {
+		return nil, err
+	}
+	verify := uint32(0)
+	if c.Verify {
+		verify = 1
+	}
+	err = binary.Write(&buffer, binary.LittleEndian, [3]uint32{uint32(c.Size), uint32(c.Rate), verify})
+	if err != nil {
+		return nil, err
+	}
+	err = binary.Write(&buffer, binary.LittleEndian, c.Model)
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// UnmarshalBinary decodes the cdf
+func (c *CDF16) UnmarshalBinary(data []byte) error {
+	reader := bytes.NewReader(data)
+	err := readHeader(reader, CDF16Snapshot)
+	if err != nil {
+		return err
+	}
+	var header [3]uint32
+	err = binary.Read(reader, binary.LittleEndian, &header)
+	if err != nil {
+		return err
+	}
+	size, rate, verify := int(header[0]), int(header[1]), header[2] != 0
+	err = Headroom(size, CDF16Scale)
+	if err != nil {
+		return err
+	}
+	if rate < 1 || rate > CDF16Fixed {
+		return fmt.Errorf("cdf rate %d is out of range", rate)
+	}
+	if expected := 2 * (size + 1); reader.Len() != expected {
+		return fmt.Errorf("cdf model is %d bytes, expected %d", reader.Len(), expected)
+	}
+	cdf := NewCDF16(verify)(size, rate).(*CDF16)
+	err = binary.Read(reader, binary.LittleEndian, cdf.Model)
+	if err != nil {
+		return err
+	}
+	err = checkCDF(cdf.Model, CDF16Scale)
+	if err != nil {
+		return err
+	}
+	*c = *cdf
+	return nil
+}
+
+// MarshalBinary encodes the cdf
+func (c *CDF32) MarshalBinary() ([]byte, error) {
+	buffer := bytes.Buffer{}
+	err := writeHeader(&buffer, CDF32Snapshot)
+	if err != nil {
+		return nil, err
+	}
+	verify := uint32(0)
+	if c.Verify {
+		verify = 1
+	}
+	err = binary.Write(&buffer, binary.LittleEndian, [3]uint32{uint32(c.Size), uint32(c.Rate), verify})
+	if err != nil {
+		return nil, err
+	}
+	err = binary.Write(&buffer, binary.LittleEndian, c.Model)
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// UnmarshalBinary decodes the cdf
+func (c *CDF32) UnmarshalBinary(data []byte) error {
+	reader := bytes.NewReader(data)
+	err := readHeader(reader, CDF32Snapshot)
+	if err != nil {
+		return err
+	}
+	var header [3]uint32
+	err = binary.Read(reader, binary.LittleEndian, &header)
+	if err != nil {
+		return err
+	}
+	size, rate, verify := int(header[0]), int(header[1]), header[2] != 0
+	err = Headroom(size, CDF32Scale)
+	if err != nil {
+		return err
+	}
+	if rate < 1 || rate > CDF32Fixed {
+		return fmt.Errorf("cdf rate %d is out of range", rate)
+	}
+	if expected := 4 * (int64(size) + 1); int64(reader.Len()) != expected {
+		return fmt.Errorf("cdf model is %d bytes, expected %d", reader.Len(), expected)
+	}
+	cdf := NewCDF32(verify)(size, rate).(*CDF32)
+	err = binary.Read(reader, binary.LittleEndian, cdf.Model)
+	if err != nil {
+		return err
+	}
+	err = checkCDF(cdf.Model, CDF32Scale)
+	if err != nil {
+		return err
+	}
+	*c = *cdf
+	return nil
+}
+
+// readCDF16 reads a cdf written as a block
+func readCDF16(reader *bytes.Reader) (*CDF16, error) {
+	data, err := readBlock(reader)
+	if err != nil {
+		return nil, err
+	}
+	c := &CDF16{}
+	err = c.UnmarshalBinary(data)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// MarshalBinary encodes the fast and slow cdfs
+func (d *DualRate16) MarshalBinary() ([]byte, error) {
+	buffer := bytes.Buffer{}
+	err := writeHeader(&buffer, DualRate16Snapshot)
+	if err != nil {
+		return nil, err
+	}
+	for _, c := range []*CDF16{d.Fast, d.Slow} {
+		data, err := c.MarshalBinary()
+		if err != nil {
+			return nil, err
+		}
+		err = writeBlock(&buffer, data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return buffer.Bytes(), nil
+}
+
+// UnmarshalBinary decodes the fast and slow cdfs
+func (d *DualRate16) UnmarshalBinary(data []byte) error {
+	reader := bytes.NewReader(data)
+	err := readHeader(reader, DualRate16Snapshot)
+	if err != nil {
+		return err
+	}
+	fast, err := readCDF16(reader)
+	if err != nil {
+		return err
+	}
+	slow, err := readCDF16(reader)
+	if err != nil {
+		return err
+	}
+	err = readEnd(reader)
+	if err != nil {
+		return err
+	}
+	if fast.Size != slow.Size {
+		return fmt.Errorf("fast cdf size %d is not slow cdf size %d", fast.Size, slow.Size)
+	}
+	*d = DualRate16{
+		Fast:   fast,
+		Slow:   slow,
+		Model:  make([]uint16, fast.Size+1),
+		Verify: fast.Verify,
+	}
+	d.mix()
+	return nil
+}
+
+// MarshalBinary encodes the rate limit, the update count and the cdf
+func (a *Adaptive16) MarshalBinary() ([]byte, error) {
+	buffer := bytes.Buffer{}
+	err := writeHeader(&buffer, Adaptive16Snapshot)
+	if err != nil {
+		return nil, err
+	}
+	err = binary.Write(&buffer, binary.LittleEndian, [2]uint32{uint32(a.Limit), uint32(a.Count)})
+	if err != nil {
+		return nil, err
+	}
+	data, err := a.CDF16.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	err = writeBlock(&buffer, data)
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// UnmarshalBinary decodes the rate limit, the update count and the cdf
+func (a *Adaptive16) UnmarshalBinary(data []byte) error {
+	reader := bytes.NewReader(data)
+	err := readHeader(reader, Adaptive16Snapshot)
+	if err != nil {
+		return err
+	}
+	var header [2]uint32
+	err = binary.Read(reader, binary.LittleEndian, &header)
+	if err != nil {
+		return err
+	}
+	limit, count := int(header[0]), int(header[1])
+	if limit < 1 || limit > CDF16Fixed {
+		return fmt.Errorf("cdf rate limit %d is out of range", limit)
+	}
+	if count >= 1<<limit {
+		return fmt.Errorf("update count %d is out of range", count)
+	}
+	c, err := readCDF16(reader)
+	if err != nil {
+		return err
+	}
+	err = readEnd(reader)
+	if err != nil {
+		return err
+	}
+	*a = Adaptive16{
+		CDF16: *c,
+		Limit: limit,
+		Count: count,
+	}
+	return nil
+}
+
+// MarshalBinary encodes the bit histories
+func (h *History16) MarshalBinary() ([]byte, error) {
+	buffer := bytes.Buffer{}
+	err := writeHeader(&buffer, History16Snapshot)
+	if err != nil {
+		return nil, err
+	}
+	verify := uint32(0)
+	if h.Verify {
+		verify = 1
+	}
+	err = binary.Write(&buffer, binary.LittleEndian, [3]uint32{uint32(h.Size), uint32(h.Rate), verify})
+	if err != nil {
+		return nil, err
+	}
+	err = binary.Write(&buffer, binary.LittleEndian, h.States)
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// UnmarshalBinary decodes the bit histories and recomputes the cdf
+func (h *History16) UnmarshalBinary(data []byte) error {
+	reader := bytes.NewReader(data)
+	err := readHeader(reader, History16Snapshot)
+	if err != nil {
+		return err
+	}
+	var header [3]uint32
+	err = binary.Read(reader, binary.LittleEndian, &header)
+	if err != nil {
+		return err
+	}
+	size, rate, verify := int(header[0]), int(header[1]), header[2] != 0
+	err = Headroom(size, CDF16Scale)
+	if err != nil {
+		return err
+	}
+	if size&(size-1) != 0 {
+		return fmt.Errorf("alphabet size %d is not a power of two", size)
+	}
+	if rate < 1 || rate > CDF16Fixed {
+		return fmt.Errorf("cdf rate %d is out of range", rate)
+	}
+	if reader.Len() != size {
+		return fmt.Errorf("bit histories are %d bytes, expected %d", reader.Len(), size)
+	}
+	states := make([]HistoryState, size)
+	err = binary.Read(reader, binary.LittleEndian, states)
+	if err != nil {
+		return err
+	}
+	limit := HistoryLimit(rate)
+	for i, state := range states {
+		if n0, n1 := state.Counts(); n0 > limit || n1 > limit {
+			return fmt.Errorf("bit history %d has counts %d and %d above %d", i, n0, n1, limit)
+		}
+	}
+	*h = *NewHistory16(verify)(size, rate).(*History16)
+	copy(h.States, states)
+	h.cdf()
+	return nil
+}
+
+// writeFilters writes the filters as blocks
+func writeFilters(buffer *bytes.Buffer, filters []Filtered16) error {
+	err := binary.Write(buffer, binary.LittleEndian, uint32(len(filters)))
+	if err != nil {
+		return err
+	}
+	for _, filter := range filters {
+		marshaler, ok := filter.(interface{ MarshalBinary() ([]byte, error) })
+		if !ok {
+			return fmt.Errorf("filter %T can not be marshaled", filter)
+		}
+		data, err := marshaler.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		err = writeBlock(buffer, data)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnmarshalFiltered16 decodes a filter of any kind
+func UnmarshalFiltered16(data []byte) (Filtered16, error) {
+	if len(data) < 8 {
+		return nil, fmt.Errorf("filter is %d bytes", len(data))
+	}
+	switch kind := binary.LittleEndian.Uint32(data[4:]); kind {
+	case CDF16Snapshot:
+		c := &CDF16{}
+		err := c.UnmarshalBinary(data)
+		if err != nil {
+			return nil, err
+		}
+		return c, nil
+	case DualRate16Snapshot:
+		d := &DualRate16{}
+		err := d.UnmarshalBinary(data)
+		if err != nil {
+			return nil, err
+		}
+		return d, nil
+	case Adaptive16Snapshot:
+		a := &Adaptive16{}
+		err := a.UnmarshalBinary(data)
+		if err != nil {
+			return nil, err
+		}
+		return a, nil
+	case History16Snapshot:
+		h := &History16{}
+		err := h.UnmarshalBinary(data)
+		if err != nil {
+			return nil, err
+		}
+		return h, nil
+	default:
+		return nil, fmt.Errorf("unknown filter kind %d", kind)
+	}
+}
+
+// readFilters reads the filters written by writeFilters
+func readFilters(reader *bytes.Reader) ([]Filtered16, error) {
+	var count uint32
+	err := binary.Read(reader, binary.LittleEndian, &count)
+	if err != nil {
+		return nil, err
+	}
+	if count > Size {
+		return nil, fmt.Errorf("%d filters, expected at most %d", count, Size)
+	}
+	filters := make([]Filtered16, count)
+	for i := range filters {
+		data, err := readBlock(reader)
+		if err != nil {
+			return nil, err
+		}
+		filters[i], err = UnmarshalFiltered16(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return filters, nil
+}
+
+// checkCounts checks that the counts of a histogram are the counts of the symbols in its
+// buffer, the unused slots of a new buffer are zeros that were never counted so symbol
+// zero can have fewer counts
+func checkCounts[T byte | uint32](vector *[256]T, buffer []byte) error {
+	var counts [256]int
+	for _, s := range buffer {
+		counts[s]++
+	}
+	if int(vector[0]) > counts[0] {
+		return fmt.Errorf("histogram count of symbol 0 is %d, the buffer has %d", vector[0], counts[0])
+	}
+	for s := 1; s < len(counts); s++ {
+		if int(vector[s]) != counts[s] {
+			return fmt.Errorf("histogram count of symbol %d is %d, the buffer has %d", s, vector[s], counts[s])
+		}
+	}
+	return nil
+}
+
+// writeHistogram writes the index, size, counts and buffer of a histogram
+func writeHistogram(buffer *bytes.Buffer, h *Histogram) error {
+	err := binary.Write(buffer, binary.LittleEndian, [2]uint32{uint32(h.Index), uint32(h.Size)})
+	if err != nil {
+		return err
+	}
+	buffer.Write(h.Vector[:])
+	buffer.Write(h.Buffer[:])
+	return nil
+}
+
+// readHistogram reads a histogram written by writeHistogram
+func readHistogram(reader *bytes.Reader, h *Histogram) error {
+	var header [2]uint32
+	err := binary.Read(reader, binary.LittleEndian, &header)
+	if err != nil {
+		return err
+	}
+	h.Index, h.Size = int(header[0]), int(header[1])
+	if h.Size < 1 || h.Size > len(h.Buffer) || h.Index >= h.Size {
+		return fmt.Errorf("histogram index %d and size %d are out of range", h.Index, h.Size)
+	}
+	err = binary.Read(reader, binary.LittleEndian, h.Vector[:])
+	if err != nil {
+		return err
+	}
+	err = binary.Read(reader, binary.LittleEndian, h.Buffer[:])
+	if err != nil {
+		return err
+	}
+	return checkCounts(&h.Vector, h.Buffer[:h.Size])
+}
+
+// writeHistograms writes the histograms
+func writeHistograms(buffer *bytes.Buffer, histograms []Histogram) error {
+	err := binary.Write(buffer, binary.LittleEndian, uint32(len(histograms)))
+	if err != nil {
+		return err
+	}
+	for i := range histograms {
+		err := writeHistogram(buffer, &histograms[i])
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readHistograms reads the histograms written by writeHistograms
+func readHistograms(reader *bytes.Reader) ([]Histogram, error) {
+	var count uint32
+	err := binary.Read(reader, binary.LittleEndian, &count)
+	if err != nil {
+		return nil, err
+	}
+	if count > Size {
+		return nil, fmt.Errorf("%d histograms, expected at most %d", count, Size)
+	}
+	histograms := make([]Histogram, count)
+	for i := range histograms {
+		err := readHistogram(reader, &histograms[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return histograms, nil
+}
+
+// MarshalBinary encodes the histogram
+func (h *Histogram) MarshalBinary() ([]byte, error) {
+	buffer := bytes.Buffer{}
+	err := writeHeader(&buffer, HistogramSnapshot)
+	if err != nil {
+		return nil, err
+	}
+	err = writeHistogram(&buffer, h)
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// UnmarshalBinary decodes the histogram
+func (h *Histogram) UnmarshalBinary(data []byte) error {
+	reader := bytes.NewReader(data)
+	err := readHeader(reader, HistogramSnapshot)
+	if err != nil {
+		return err
+	}
+	var histogram Histogram
+	err = readHistogram(reader, &histogram)
+	if err != nil {
+		return err
+	}
+	err = readEnd(reader)
+	if err != nil {
+		return err
+	}
+	*h = histogram
+	return nil
+}
+
+// MarshalBinary encodes the ring
+func (h *Ring) MarshalBinary() ([]byte, error) {
+	buffer := bytes.Buffer{}
+	err := writeHeader(&buffer, RingSnapshot)
+	if err != nil {
+		return nil, err
+	}
+	err = binary.Write(&buffer, binary.LittleEndian, [2]uint32{uint32(h.Index), uint32(len(h.Buffer))})
+	if err != nil {
+		return nil, err
+	}
+	err = binary.Write(&buffer, binary.LittleEndian, h.Vector)
+	if err != nil {
+		return nil, err
+	}
+	buffer.Write(h.Buffer)
+	return buffer.Bytes(), nil
+}
+
+// UnmarshalBinary decodes the ring
+func (h *Ring) UnmarshalBinary(data []byte) error {
+	reader := bytes.NewReader(data)
+	err := readHeader(reader, RingSnapshot)
+	if err != nil {
+		return err
+	}
+	var header [2]uint32
+	err = binary.Read(reader, binary.LittleEndian, &header)
+	if err != nil {
+		return err
+	}
+	index, size := int64(header[0]), int64(header[1])
+	if size < 1 || index >= size {
+		return fmt.Errorf("ring index %d and size %d are out of range", index, size)
+	}
+	if expected := 4*256 + size; int64(reader.Len()) != expected {
+		return fmt.Errorf("ring is %d bytes, expected %d", reader.Len(), expected)
+	}
+	ring := NewRing(int(size))
+	ring.Index = int(index)
+	err = binary.Read(reader, binary.LittleEndian, &ring.Vector)
+	if err != nil {
+		return err
+	}
+	_, err = reader.Read(ring.Buffer)
+	if err != nil {
+		return err
+	}
+	err = checkCounts(&ring.Vector, ring.Buffer)
+	if err != nil {
+		return err
+	}
+	*h = *ring
+	return nil
+}
+
+// MarshalBinary encodes the half life, weight and counts
+func (h *Decay) MarshalBinary() ([]byte, error) {
+	buffer := bytes.Buffer{}
+	err := writeHeader(&buffer, DecaySnapshot)
+	if err != nil {
+		return nil, err
+	}
+	err = binary.Write(&buffer, binary.LittleEndian, h.HalfLife)
+	if err != nil {
+		return nil, err
+	}
+	err = binary.Write(&buffer, binary.LittleEndian, h.Weight)
+	if err != nil {
+		return nil, err
+	}
+	err = binary.Write(&buffer, binary.LittleEndian, h.Vector)
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// UnmarshalBinary decodes the half life, weight and counts
+func (h *Decay) UnmarshalBinary(data []byte) error {
+	reader := bytes.NewReader(data)
+	err := readHeader(reader, DecaySnapshot)
+	if err != nil {
+		return err
+	}
+	var halfLife float64
+	err = binary.Read(reader, binary.LittleEndian, &halfLife)
+	if err != nil {
+		return err
+	}
+	if !(halfLife > 0) || math.IsInf(halfLife, 0) {
+		return fmt.Errorf("half life %f is out of range", halfLife)
+	}
+	decay := NewDecay(halfLife)
+	err = binary.Read(reader, binary.LittleEndian, &decay.Weight)
+	if err != nil {
+		return err
+	}
+	if !(decay.Weight >= 1 && decay.Weight < DecayRescale) {
+		return fmt.Errorf("weight %f is out of range", decay.Weight)
+	}
+	err = binary.Read(reader, binary.LittleEndian, &decay.Vector)
+	if err != nil {
+		return err
+	}
+	for i, v := range decay.Vector {
+		if !(v >= 0) || math.IsInf(float64(v), 0) {
+			return fmt.Errorf("count %d is %f", i, v)
+		}
+	}
+	err = readEnd(reader)
+	if err != nil {
+		return err
+	}
+	*h = *decay
+	return nil
+}
+
+// writeCounts writes the histograms as blocks
+func writeCounts(buffer *bytes.Buffer, histograms []Counts) error {
+	err := binary.Write(buffer, binary.LittleEndian, uint32(len(histograms)))
+	if err != nil {
+		return err
+	}
+	for _, h := range histograms {
+		marshaler, ok := h.(interface{ MarshalBinary() ([]byte, error) })
+		if !ok {
+			return fmt.Errorf("histogram %T can not be marshaled", h)
+		}
+		data, err := marshaler.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		err = writeBlock(buffer, data)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// UnmarshalCounts decodes a histogram of any kind
+func UnmarshalCounts(data []byte) (Counts, error) {
+	if len(data) < 8 {
+		return nil, fmt.Errorf("histogram is %d bytes", len(data))
+	}
+	switch kind := binary.LittleEndian.Uint32(data[4:]); kind {
+	case HistogramSnapshot:
+		h := &Histogram{}
+		err := h.UnmarshalBinary(data)
+		if err != nil {
+			return nil, err
+		}
+		return h, nil
+	case RingSnapshot:
+		h := &Ring{}
+		err := h.UnmarshalBinary(data)
+		if err != nil {
+			return nil, err
+		}
+		return h, nil
+	case DecaySnapshot:
+		h := &Decay{}
+		err := h.UnmarshalBinary(data)
+		if err != nil {
+			return nil, err
+		}
+		return h, nil
+	default:
+		return nil, fmt.Errorf("unknown histogram kind %d", kind)
+	}
+}
+
+// readCounts reads the histograms written by writeCounts
+func readCounts(reader *bytes.Reader) ([]Counts, error) {
+	var count uint32
+	err := binary.Read(reader, binary.LittleEndian, &count)
+	if err != nil {
+		return nil, err
+	}
+	if count > Size {
+		return nil, fmt.Errorf("%d histograms, expected at most %d", count, Size)
+	}
+	histograms := make([]Counts, count)
+	for i := range histograms {
+		data, err := readBlock(reader)
+		if err != nil {
+			return nil, err
+		}
+		histograms[i], err = UnmarshalCounts(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return histograms, nil
+}
+
+// MarshalBinary encodes the markov history and filters, the attention and encoding are
+// configuration and are not part of the snapshot
+func (f *Filtered) MarshalBinary() ([]byte, error) {
+	buffer := bytes.Buffer{}
+	err := writeHeader(&buffer, FilteredSnapshot)
+	if err != nil {
+		return nil, err
+	}
+	buffer.Write(f.Markov[:])
+	err = writeFilters(&buffer, f.Filters)
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// UnmarshalBinary decodes the markov history and filters, keeping the attention and encoding
+func (f *Filtered) UnmarshalBinary(data []byte) error {
+	reader := bytes.NewReader(data)
+	err := readHeader(reader, FilteredSnapshot)
+	if err != nil {
+		return err
+	}
+	var markov Markov
+	err = binary.Read(reader, binary.LittleEndian, &markov)
+	if err != nil {
+		return err
+	}
+	filters, err := readFilters(reader)
+	if err != nil {
+		return err
+	}
+	err = readEnd(reader)
+	if err != nil {
+		return err
+	}
+	f.Markov, f.Filters, f.Workspace = markov, filters, NewWorkspace()
+	return nil
+}
+
+// MarshalBinary encodes the markov history and histograms
+func (m *Mixer) MarshalBinary() ([]byte, error) {
+	buffer := bytes.Buffer{}
+	err := writeHeader(&buffer, MixerSnapshot)
+	if err != nil {
+		return nil, err
+	}
+	buffer.Write(m.Markov[:])
+	err = writeCounts(&buffer, m.Histograms)
+	if err != nil {
+		return nil, err
+	}
+	return buffer.Bytes(), nil
+}
+
+// UnmarshalBinary decodes the markov history and histograms
+func (m *Mixer) UnmarshalBinary(data []byte) error {
+	reader := bytes.NewReader(data)
+	err := readHeader(reader, MixerSnapshot)
+	if err != nil {
+		return err
+	}
+	var markov Markov
+	err = binary.Read(reader, binary.LittleEndian, &markov)
+	if err != nil {
+		return err
+	}
+	histograms, err := readCounts(reader)
+	if err != nil {
+		return err
+	}
+	err = readEnd(reader)
+	if err != nil {
+		return err
+	}
+	m.Markov, m.Histograms, m.Workspace = markov, histograms, NewWorkspace()
+	return nil
+}
+
+// MarshalBinary encodes the markov histories and filters of both streams
+func (f *CrossFiltered) MarshalBinary() ([]byte, error) {
+	buffer := bytes.Buffer{}
+	err := writeHeader(&buffer, CrossFilteredSnapshot)
+	if err != nil {
+		return nil, err
+	}
+	for i := range f.Filters {
+		buffer.Write(f.Markov[i][:])
+		err = writeFilters(&buffer, f.Filters[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return buffer.Bytes(), nil
+}
+
+// UnmarshalBinary decodes the markov histories and filters of both streams
+func (f *CrossFiltered) UnmarshalBinary(data []byte) error {
+	reader := bytes.NewReader(data)
+	err := readHeader(reader, CrossFilteredSnapshot)
+	if err != nil {
+		return err
+	}
+	var markov [2]Markov
+	filters := [2][]Filtered16{}
+	for i := range filters {
+		err = binary.Read(reader, binary.LittleEndian, &markov[i])
+		if err != nil {
+			return err
+		}
+		filters[i], err = readFilters(reader)
+		if err != nil {
+			return err
+		}
+	}
+	err = readEnd(reader)
+	if err != nil {
+		return err
+	}
+	f.Markov, f.Filters = markov, filters
+	f.Workspaces = [2]*Workspace{NewWorkspace(), NewWorkspace()}
+	return nil
+}
+
+// MarshalBinary encodes the markov histories and histograms of both streams
+func (m *CrossMixer) MarshalBinary() ([]byte, error) {
+	buffer := bytes.Buffer{}
+	err := writeHeader(&buffer, CrossMixerSnapshot)
+	if err != nil {
+		return nil, err
+	}
+	for i := range m.Histograms {
+		buffer.Write(m.Markov[i][:])
+		err = writeHistograms(&buffer, m.Histograms[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return buffer.Bytes(), nil
+}
+
+// UnmarshalBinary decodes the markov histories and histograms of both streams
+func (m *CrossMixer) UnmarshalBinary(data []byte) error {
+	reader := bytes.NewReader(data)
+	err := readHeader(reader, CrossMixerSnapshot)
+	if err != nil {
+		return err
+	}
+	var markov [2]Markov
+	histograms := [2][]Histogram{}
+	for i := range histograms {
+		err = binary.Read(reader, binary.LittleEndian, &markov[i])
+		if err != nil {
+			return err
+		}
+		histograms[i], err = readHistograms(reader)
+		if err != nil {
+			return err
+		}
+	}
+	err = readEnd(reader)
+	if err != nil {
+		return err
+	}
+	m.Markov, m.Histograms = markov, histograms
+	m.Workspaces = [2]*Workspace{NewWorkspace(), NewWorkspace()}
+	return nil
+}
+
+// Snapshot encodes the state of a mixer
+func Snapshot(m Mix) ([]byte, error) {
+	marshaler, ok := m.(interface{ MarshalBinary() ([]byte, error) })
+	if !ok {
+		return nil, fmt.Errorf("mixer %T can not be marshaled", m)
+	}
+	return marshaler.MarshalBinary()
+}
+
+// Restore decodes a mixer of any kind from a snapshot
+func Restore(data []byte) (Mix, error) {
+	if len(data) < 8 {
+		return nil, fmt.Errorf("snapshot is %d bytes", len(data))
+	}
+	switch kind := binary.LittleEndian.Uint32(data[4:]); kind {
+	case FilteredSnapshot:
+		f := &Filtered{}
+		err := f.UnmarshalBinary(data)
+		if err != nil {
+			return nil, err
+		}
+		return f, nil
+	case MixerSnapshot:
+		m := &Mixer{}
+		err := m.UnmarshalBinary(data)
+		if err != nil {
+			return nil, err
+		}
+		return m, nil
+	default:
+		return nil, fmt.Errorf("snapshot kind %d is not a mixer", kind)
+	}
+}
+
+// RestoreCross decodes a cross mixer of any kind from a snapshot
+func RestoreCross(data []byte) (CrossMix, error) {
+	if len(data) < 8 {
+		return nil, fmt.Errorf("snapshot is %d bytes", len(data))
+	}
+	switch kind := binary.LittleEndian.Uint32(data[4:]); kind {
+	case CrossFilteredSnapshot:
+		f := &CrossFiltered{}
+		err := f.UnmarshalBinary(data)
+		if err != nil {
+			return nil, err
+		}
+		return f, nil
+	case CrossMixerSnapshot:
+		m := &CrossMixer{}
+		err := m.UnmarshalBinary(data)
+		if err != nil {
+			return nil, err
+		}
+		return m, nil
+	default:
+		return nil, fmt.Errorf("snapshot kind %d is not a cross mixer", kind)
+	}
+}
@@ -0,0 +1,154 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math/rand"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	mixers := []Mix{NewFiltered(), NewMixer(), NewMixer(NewDecay(4), NewRing(1000), NewDecay(300))}
+	for _, m := range mixers {
+		for i := 0; i < 300; i++ {
+			m.Add(byte(rng.Intn(256)))
+		}
+		data, err := Snapshot(m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		restored, err := Restore(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 8; i++ {
+			if a, b := m.Mix(), restored.Mix(); a != b {
+				t.Fatalf("%T restored mix differs", m)
+			}
+			s := byte(rng.Intn(256))
+			m.Add(s)
+			restored.Add(s)
+		}
+		if _, err := Restore(data[:len(data)-1]); err == nil {
+			t.Fatalf("%T expected an error for truncated data", m)
+		}
+		data[0]++
+		if _, err := Restore(data); err == nil {
+			t.Fatalf("%T expected an error for a different version", m)
+		}
+	}
+}
+
+func TestCrossSnapshot(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	mixers := []CrossMix{NewCrossFiltered(), NewCrossMixer()}
+	for _, m := range mixers {
+		for i := 0; i < 300; i++ {
+			m.Add(byte(rng.Intn(256)), byte(rng.Intn(256)))
+		}
+		data, err := m.(interface{ MarshalBinary() ([]byte, error) }).MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		restored, err := RestoreCross(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := 0; i < 8; i++ {
+			if a, b := m.Mix(), restored.Mix(); a != b {
+				t.Fatalf("%T restored mix differs", m)
+			}
+			s1, s2 := byte(rng.Intn(256)), byte(rng.Intn(256))
+			m.Add(s1, s2)
+			restored.Add(s1, s2)
+		}
+		if _, err := RestoreCross(append(data, 0)); err == nil {
+			t.Fatalf("%T expected an error for trailing data", m)
+		}
+	}
+}
+
+func TestSnapshotKeepsAttention(t *testing.T) {
+	f := NewFiltered()
+	f.Attention = NewMultiHeadAttention(rand.New(rand.NewSource(1)), 2, 8)
+	f.Add('a')
+	data, err := f.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := NewFiltered()
+	g.Attention = f.Attention
+	if err := g.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if g.Attention == nil {
+		t.Fatal("attention was not kept")
+	}
+	if a, b := f.Mix(), g.Mix(); a != b {
+		t.Fatal("restored mix differs")
+	}
+}
+
+func TestSnapshotValidation(t *testing.T) {
+	c := NewCDF16(false)(16, 4).(*CDF16)
+	c.Update(3)
+	data, err := c.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// model entry 2 is above the scale and breaks Blend16
+	binary.LittleEndian.PutUint16(data[8+12+2*2:], 1<<15)
+	if err := (&CDF16{}).UnmarshalBinary(data); err == nil {
+		t.Fatal("expected an error for an invalid cdf")
+	}
+
+	ring := NewRing(16)
+	for i := 0; i < 20; i++ {
+		ring.Add(byte(i))
+	}
+	data, err = ring.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := (&Ring{}).UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	binary.LittleEndian.PutUint32(data[8+8+4*5:], 100)
+	if err := (&Ring{}).UnmarshalBinary(data); err == nil {
+		t.Fatal("expected an error for ring counts that are not the buffer counts")
+	}
+
+	m := NewMixer()
+	for i := 0; i < 300; i++ {
+		m.Add(byte(i * 7))
+	}
+	histogram := *m.Histograms[0].(*Histogram)
+	histogram.Vector[histogram.Buffer[histogram.Index]]++
+	buffer := bytes.Buffer{}
+	if err := writeHistogram(&buffer, &histogram); err != nil {
+		t.Fatal(err)
+	}
+	if err := readHistogram(bytes.NewReader(buffer.Bytes()), &Histogram{}); err == nil {
+		t.Fatal("expected an error for histogram counts that are not the buffer counts")
+	}
+
+	data, err = Snapshot(NewFiltered())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored, err := Restore(data[:len(data)-1]); err == nil || restored != nil {
+		t.Fatalf("expected a nil mixer and an error, got %T and %v", restored, err)
+	}
+	data, err = NewCrossMixer().MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored, err := RestoreCross(data[:len(data)-1]); err == nil || restored != nil {
+		t.Fatalf("expected a nil cross mixer and an error, got %T and %v", restored, err)
+	}
+}
@@ -0,0 +1,177 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"index/suffixarray"
+	"io"
+	"math/rand"
+	"os"
+)
+
+const (
+	// MaxMatch is the longest context suffix matched against the corpus
+	MaxMatch = 64
+	// SuffixLambda controls how fast the suffix array distribution takes over as the match grows
+	SuffixLambda = 4
+	// SuffixCount is the most occurrences of a pattern or of a pattern and its next symbol
+	// that are looked up in the suffix array, which bounds the work of short matches
+	SuffixCount = 1 << 12
+)
+
+// Distribution is a next symbol probability distribution
+type Distribution [256]float64
+
+// NewDistribution normalizes a histogram into a distribution
+func NewDistribution(histogram [256]uint) (d Distribution) {
+	sum := uint(0)
+	for _, v := range histogram {
+		sum += v
+	}
+	if sum == 0 {
+		return d
+	}
+	for i, v := range histogram {
+		d[i] = float64(v) / float64(sum)
+	}
+	return d
+}
+
+// Sample samples a symbol from the distribution
+func (d *Distribution) Sample(rng *rand.Rand) byte {
+	total, selected := 0.0, rng.Float64()
+	for i, v := range d {
+		total += v
+		if selected < total {
+			return byte(i)
+		}
+	}
+	return 0
+}
+
+// Suffix is a suffix array over the training corpus
+type Suffix struct {
+	Index  *suffixarray.Index
+	Corpus []byte
+}
+
+// NewSuffix builds a suffix array over the corpus
+func NewSuffix(corpus []byte) *Suffix {
+	return &Suffix{
+		Index:  suffixarray.New(corpus),
+		Corpus: corpus,
+	}
+}
+
+// ReadSuffix reads a suffix array
+func ReadSuffix(r io.Reader) (*Suffix, error) {
+	index := new(suffixarray.Index)
+	err := index.Read(bufio.NewReader(r))
+	if err != nil {
+		return nil, err
+	}
+	return &Suffix{
+		Index:  index,
+		Corpus: index.Bytes(),
+	}, nil
+}
+
+// Write writes the suffix array
+func (s *Suffix) Write(w io.Writer) error {
+	buffer := bufio.NewWriter(w)
+	err := s.Index.Write(buffer)
+	if err != nil {
+		return err
+	}
+	return buffer.Flush()
+}
+
+// Next counts the symbols that follow the occurrences of pattern in the corpus, a pattern
+// with more than SuffixCount occurrences is counted by looking up each next symbol, so the
+// count of a symbol is at most SuffixCount
+func (s *Suffix) Next(pattern []byte) (histogram [256]uint, found bool) {
+	offsets := s.Index.Lookup(pattern, SuffixCount+1)
+	if len(offsets) <= SuffixCount {
+		for _, offset := range offsets {
+			if next := offset + len(pattern); next < len(s.Corpus) {
+				histogram[s.Corpus[next]]++
+				found = true
+			}
+		}
+		return histogram, found
+	}
+	extended := make([]byte, len(pattern)+1)
+	copy(extended, pattern)
+	for i := range histogram {
+		extended[len(pattern)] = byte(i)
+		if count := len(s.Index.Lookup(extended, SuffixCount)); count > 0 {
+			histogram[i] = uint(count)
+			found = true
+		}
+	}
+	return histogram, found
+}
+
+// Match finds the longest suffix of context that occurs in the corpus with a continuation
+// and returns its length and the histogram of the symbols that follow it
+func (s *Suffix) Match(context []byte) (length int, histogram [256]uint) {
+	if len(context) > MaxMatch {
+		context = context[len(context)-MaxMatch:]
+	}
+	low, high := 0, len(context)
+	for low < high {
+		middle := (low + high + 1) / 2
+		if len(s.Index.Lookup(context[len(context)-middle:], 1)) > 0 {
+			low = middle
+		} else {
+			high = middle - 1
+		}
+	}
+	for length = low; length > 0; length-- {
+		if histogram, found := s.Next(context[len(context)-length:]); found {
+			return length, histogram
+		}
+	}
+	return 0, histogram
+}
+
+// Interpolate blends the vector table distribution with the suffix array match, infini-gram style
+func Interpolate(a Distribution, length int, suffix [256]uint) Distribution {
+	b := NewDistribution(suffix)
+	if length == 0 {
+		return a
+	}
+	sum := 0.0
+	for _, v := range a {
+		sum += v
+	}
+	lambda := float64(length) / float64(length+SuffixLambda)
+	if sum == 0 {
+		lambda = 1
+	}
+	var d Distribution
+	for i := range d {
+		d[i] = lambda*b[i] + (1-lambda)*a[i]
+	}
+	return d
+}
+
+// OpenSuffix reads the suffix array file, or returns nil if there is no file
+func OpenSuffix(name string) *Suffix {
+	if name == "" {
+		return nil
+	}
+	in, err := os.Open(name)
+	if err != nil {
+		panic(err)
+	}
+	defer in.Close()
+	suffix, err := ReadSuffix(in)
+	if err != nil {
+		panic(err)
+	}
+	return suffix
+}
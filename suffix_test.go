@@ -0,0 +1,77 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"math"
+	"testing"
+)
+
+func TestSuffixMatch(t *testing.T) {
+	s := NewSuffix([]byte("to be or not to be, that is the question"))
+	length, histogram := s.Match([]byte("xto be"))
+	if length != 5 {
+		t.Fatalf("%d != 5", length)
+	}
+	if histogram[' '] != 1 || histogram[','] != 1 {
+		t.Fatalf("incorrect histogram %d %d", histogram[' '], histogram[','])
+	}
+	length, _ = s.Match([]byte("zzz"))
+	if length != 0 {
+		t.Fatalf("%d != 0", length)
+	}
+	length, histogram = s.Match([]byte("question"))
+	if length != 1 || histogram['o'] != 1 {
+		t.Fatalf("match at the end of the corpus has no continuation %d", length)
+	}
+
+	buffer := bytes.Buffer{}
+	err := s.Write(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r, err := ReadSuffix(&buffer)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(r.Corpus, s.Corpus) {
+		t.Fatal("corpus not restored")
+	}
+}
+
+func TestInterpolate(t *testing.T) {
+	table, suffix := [256]uint{}, [256]uint{}
+	table['a'], suffix['b'] = 1, 1
+	d := Interpolate(NewDistribution(table), 4, suffix)
+	if d['a'] != .5 || d['b'] != .5 {
+		t.Fatalf("%f %f", d['a'], d['b'])
+	}
+	d = Interpolate(Distribution{}, 1, suffix)
+	if d['b'] != 1 {
+		t.Fatalf("%f != 1", d['b'])
+	}
+	sum := 0.0
+	for _, v := range Interpolate(NewDistribution(table), 7, suffix) {
+		sum += v
+	}
+	if math.Abs(sum-1) > 1e-9 {
+		t.Fatalf("%f != 1", sum)
+	}
+}
+
+func TestSuffixNext(t *testing.T) {
+	corpus := bytes.Repeat([]byte("ab"), SuffixCount)
+	corpus = append(corpus, bytes.Repeat([]byte("ac"), 16)...)
+	s := NewSuffix(corpus)
+	histogram, found := s.Next([]byte("a"))
+	if !found || histogram['b'] != SuffixCount || histogram['c'] != 16 {
+		t.Fatalf("%t %d %d", found, histogram['b'], histogram['c'])
+	}
+	histogram, found = s.Next([]byte("ac"))
+	if !found || histogram['a'] != 15 {
+		t.Fatalf("%t %d", found, histogram['a'])
+	}
+}
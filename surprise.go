@@ -0,0 +1,148 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Line is the surprisal of a line of a document
+type Line struct {
+	Number      int       `json:"line"`
+	Offset      int       `json:"offset"`
+	Text        string    `json:"text"`
+	Bytes       []float64 `json:"bytes"`
+	Bits        float64   `json:"bits"`
+	BitsPerByte float64   `json:"bits_per_byte"`
+	Max         float64   `json:"max"`
+}
+
+// Report is the surprisal report of a document
+type Report struct {
+	Bytes       int     `json:"bytes"`
+	Bits        float64 `json:"bits"`
+	BitsPerByte float64 `json:"bits_per_byte"`
+	Lines       []Line  `json:"lines"`
+}
+
+// Surprise computes the surprisal in bits of each byte and line of the document
+func Surprise(m *Model, document []byte) Report {
+	scored := Score(m, document)
+	report := Report{
+		Bytes:       len(document),
+		Bits:        -scored.Total / math.Ln2,
+		BitsPerByte: scored.BitsPerByte,
+	}
+	line := Line{Number: 1}
+	flush := func(end int) {
+		line.Text = string(document[line.Offset:end])
+		if len(line.Bytes) > 0 {
+			line.BitsPerByte = line.Bits / float64(len(line.Bytes))
+		}
+		report.Lines = append(report.Lines, line)
+	}
+	for i, lp := range scored.LogProbs {
+		bits := -lp / math.Ln2
+		line.Bytes = append(line.Bytes, bits)
+		line.Bits += bits
+		if bits > line.Max {
+			line.Max = bits
+		}
+		if document[i] == '\n' {
+			flush(i + 1)
+			line = Line{Number: line.Number + 1, Offset: i + 1}
+		}
+	}
+	if len(line.Bytes) > 0 {
+		flush(len(document))
+	}
+	return report
+}
+
+// WriteJSON writes the report as JSON
+func (r Report) WriteJSON(w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(r)
+}
+
+// WriteTSV writes the report with one row per line and the byte surprisals comma separated
+func (r Report) WriteTSV(w io.Writer) error {
+	buffer := bufio.NewWriter(w)
+	fmt.Fprintf(buffer, "line\toffset\tbits\tbits_per_byte\tmax\tbytes\ttext\n")
+	for _, line := range r.Lines {
+		bytes := make([]string, len(line.Bytes))
+		for i, v := range line.Bytes {
+			bytes[i] = strconv.FormatFloat(v, 'f', 3, 64)
+		}
+		fmt.Fprintf(buffer, "%d\t%d\t%.3f\t%.3f\t%.3f\t%s\t%s\n", line.Number, line.Offset,
+			line.Bits, line.BitsPerByte, line.Max, strings.Join(bytes, ","),
+			strconv.Quote(strings.TrimSuffix(line.Text, "\n")))
+	}
+	return buffer.Flush()
+}
+
+// Color is the ANSI color for a surprisal in bits
+func Color(bits float64) string {
+	switch {
+	case bits < 2:
+		return "\x1b[0m"
+	case bits < 4:
+		return "\x1b[32m"
+	case bits < 8:
+		return "\x1b[33m"
+	case bits < 12:
+		return "\x1b[31m"
+	}
+	return "\x1b[1;37;41m"
+}
+
+// WriteANSI writes the document colored by surprisal, prefixing each line with its bits per byte
+func (r Report) WriteANSI(w io.Writer) error {
+	buffer := bufio.NewWriter(w)
+	for _, line := range r.Lines {
+		fmt.Fprintf(buffer, "%s%6.3f\x1b[0m ", Color(line.BitsPerByte), line.BitsPerByte)
+		text := line.Text
+		for i := 0; i < len(text); {
+			s, size := utf8.DecodeRuneInString(text[i:])
+			bits := 0.0
+			for _, v := range line.Bytes[i : i+size] {
+				if v > bits {
+					bits = v
+				}
+			}
+			switch {
+			case s == '\n':
+			case s == utf8.RuneError || (s < ' ' && s != '\t'):
+				fmt.Fprintf(buffer, "%s%s", Color(bits), strconv.QuoteToASCII(text[i:i+size]))
+			default:
+				fmt.Fprintf(buffer, "%s%s", Color(bits), text[i:i+size])
+			}
+			i += size
+		}
+		fmt.Fprintf(buffer, "\x1b[0m\n")
+	}
+	return buffer.Flush()
+}
+
+// Write writes the report in the format json, tsv or ansi
+func (r Report) Write(w io.Writer, format string) error {
+	switch format {
+	case "json":
+		return r.WriteJSON(w)
+	case "tsv":
+		return r.WriteTSV(w)
+	case "ansi":
+		return r.WriteANSI(w)
+	}
+	return fmt.Errorf("unknown format %s", format)
+}
@@ -0,0 +1,50 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bytes"
+	"math"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestSurprise(t *testing.T) {
+	transforms := GetTransforms()
+	m := NewModel(&transforms, nil, NewSuffix([]byte("INFO ok\nINFO ok\nINFO ok\n")), false)
+	report := Surprise(m, []byte("INFO ok\nERROR disk on fire\nINFO ok"))
+	if len(report.Lines) != 3 {
+		t.Fatalf("%d != 3", len(report.Lines))
+	}
+	bits := 0.0
+	for _, line := range report.Lines {
+		bits += line.Bits
+	}
+	if math.Abs(bits-report.Bits) > 1e-6 {
+		t.Fatalf("%f != %f", bits, report.Bits)
+	}
+	if report.Lines[1].BitsPerByte <= report.Lines[2].BitsPerByte {
+		t.Fatalf("%f <= %f", report.Lines[1].BitsPerByte, report.Lines[2].BitsPerByte)
+	}
+	if report.Lines[2].Text != "INFO ok" {
+		t.Fatalf("%q", report.Lines[2].Text)
+	}
+
+	for _, format := range []string{"json", "tsv", "ansi"} {
+		buffer := bytes.Buffer{}
+		err := report.Write(&buffer, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		text := regexp.MustCompile("\x1b\\[[0-9;]*m").ReplaceAllString(buffer.String(), "")
+		if !strings.Contains(text, "disk on fire") {
+			t.Fatalf("%s output is missing text", format)
+		}
+	}
+	if err := report.Write(&bytes.Buffer{}, "xml"); err == nil {
+		t.Fatal("expected an error for an unknown format")
+	}
+}
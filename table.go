@@ -0,0 +1,224 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/pointlander/v/vector"
+)
+
+const (
+	// Transforms is the number of vector transforms
+	Transforms = 512
+	// Backoff is the largest search window radius
+	Backoff = 1024
+	// TableVersion is the version of the table file format, version 1 is the first with
+	// table info and with the markov rows of the Filtered mixer in the keys, tables without
+	// table info were keyed without them
+	TableVersion = 1
+)
+
+// Keys computes the slot table keys of a context vector
+func Keys(transforms *[Transforms][256]float32, vv *[InputSize]float32) (keys [Transforms]uint32) {
+	for i := range transforms {
+		keys[i] = math.Float32bits(2*float32(i) + vector.Dot(vv[:], transforms[i][:]))
+	}
+	return keys
+}
+
+// Window reads the slots within radius of index, each slot is width bytes
+func Window(r io.ReaderAt, slots, index, radius, width int64) []byte {
+	begin, end := index-radius, index+radius
+	if begin < 0 {
+		begin = 0
+	}
+	if end > slots-1 {
+		end = slots - 1
+	}
+	buffer := make([]byte, (end-begin+1)*width)
+	n, err := r.ReadAt(buffer, begin*width)
+	if err != nil && err != io.EOF {
+		panic(err)
+	}
+	return buffer[:n]
+}
+
+// Symbols is an in memory slot table of next symbols
+type Symbols struct {
+	Bits  uint
+	Slots []byte
+}
+
+// NewSymbols makes a new symbol table with 2^bits slots
+func NewSymbols(bits uint) *Symbols {
+	return &Symbols{
+		Bits:  bits,
+		Slots: make([]byte, 1<<bits),
+	}
+}
+
+// Index maps a key to a slot index
+func (s *Symbols) Index(key uint32) uint32 {
+	return key & uint32(len(s.Slots)-1)
+}
+
+// Set stores the next symbol for the keys
+func (s *Symbols) Set(keys *[Transforms]uint32, symbol byte) {
+	for _, key := range keys {
+		s.Slots[s.Index(key)] = symbol
+	}
+}
+
+// Table returns a table for searching the in memory slots
+func (s *Symbols) Table() *SymbolTable {
+	return &SymbolTable{
+		Slots: int64(len(s.Slots)),
+		Table: bytes.NewReader(s.Slots),
+	}
+}
+
+// TableInfo records how a symbol or offset table was trained, it is written after the slots
+// so the table is keyed the same way when it is opened
+type TableInfo struct {
+	Version uint32
+	Counter uint32
+	Bits    uint32
+}
+
+// Write writes the slots followed by the table info with the current version
+func (s *Symbols) Write(w io.Writer, info TableInfo) error {
+	info.Version = TableVersion
+	buffer := bufio.NewWriter(w)
+	_, err := buffer.Write(s.Slots)
+	if err != nil {
+		return err
+	}
+	err = binary.Write(buffer, binary.LittleEndian, info)
+	if err != nil {
+		return err
+	}
+	return buffer.Flush()
+}
+
+// SymbolTable is a searchable symbol table, a bit table holds the binary decisions of
+// each byte instead of the bytes
+type SymbolTable struct {
+	Slots   int64
+	Table   io.ReaderAt
+	Counter Counter
+	Bits    bool
+}
+
+// openTable reads the size of a table file of slots of width bytes and the table info after
+// the slots, a table of another version is rejected because its keys are different
+func openTable(file *os.File, width int64) (int64, TableInfo, error) {
+	var info TableInfo
+	stat, err := file.Stat()
+	if err != nil {
+		return 0, info, err
+	}
+	size, trailer := stat.Size(), int64(binary.Size(info))
+	power := func(size int64) bool {
+		slots := size / width
+		return size%width == 0 && slots > 0 && slots&(slots-1) == 0
+	}
+	if power(size) {
+		return 0, info, fmt.Errorf("table has no table info, it is older than version %d and has to be trained again", TableVersion)
+	}
+	if !power(size - trailer) {
+		return 0, info, fmt.Errorf("table size %d is not a power of two", (size-trailer)/width)
+	}
+	err = binary.Read(io.NewSectionReader(file, size-trailer, trailer), binary.LittleEndian, &info)
+	if err != nil {
+		return 0, info, err
+	}
+	if info.Version != TableVersion {
+		return 0, info, fmt.Errorf("table version is %d, expected %d", info.Version, TableVersion)
+	}
+	if info.Counter >= uint32(len(Counters)) {
+		return 0, info, fmt.Errorf("table counter %d is unknown", info.Counter)
+	}
+	if info.Bits > 1 {
+		return 0, info, fmt.Errorf("table bit mode %d is unknown", info.Bits)
+	}
+	return (size - trailer) / width, info, nil
+}
+
+// OpenSymbols opens a symbol table
+func OpenSymbols(file *os.File) (*SymbolTable, error) {
+	slots, info, err := openTable(file, 1)
+	if err != nil {
+		return nil, err
+	}
+	return &SymbolTable{
+		Slots:   slots,
+		Table:   file,
+		Counter: Counter(info.Counter),
+		Bits:    info.Bits == 1,
+	}, nil
+}
+
+// Search searches the symbol table for the keys, widening the window until a symbol is found
+func (s *SymbolTable) Search(keys *[Transforms]uint32) (histogram [256]uint) {
+	found := false
+	for i := int64(1); i < Backoff && !found; i *= 2 {
+		for _, key := range keys {
+			index := int64(key) & (s.Slots - 1)
+			for _, v := range Window(s.Table, s.Slots, index, i, 1) {
+				if v != 0 {
+					found = true
+					histogram[v]++
+				}
+			}
+		}
+	}
+	return histogram
+}
+
+// Train trains the symbol table and optionally the offset table on data with the keys from m
+func Train(m Mix, data []byte, symbols *Symbols, offsets *Offsets, verbose bool) {
+	m.Add(0)
+	transforms := GetTransforms()
+	for j, v := range data {
+		vv := m.Mix()
+		keys := Keys(&transforms, &vv)
+		symbols.Set(&keys, v)
+		if offsets != nil {
+			offsets.Set(&keys, j)
+		}
+		m.Add(v)
+		if verbose {
+			fmt.Println(float64(j) / float64(len(data)))
+		}
+	}
+}
+
+// HitRate is the fraction of data for which the most frequent symbol found in the table is correct
+func HitRate(m Mix, table *SymbolTable, data []byte) float64 {
+	if len(data) == 0 {
+		return 0
+	}
+	m.Add(0)
+	transforms := GetTransforms()
+	hits := 0
+	for _, v := range data {
+		vv := m.Mix()
+		keys := Keys(&transforms, &vv)
+		histogram := table.Search(&keys)
+		d := NewDistribution(histogram)
+		if histogram[v] > 0 && d.Top(1)[0] == v {
+			hits++
+		}
+		m.Add(v)
+	}
+	return float64(hits) / float64(len(data))
+}
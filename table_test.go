@@ -0,0 +1,80 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestTableInfo(t *testing.T) {
+	symbols := NewSymbols(10)
+	symbols.Slots[3] = 'a'
+	open := func(write func(*os.File) error) (*SymbolTable, error) {
+		name := filepath.Join(t.TempDir(), "model.bin")
+		out, err := os.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = write(out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		out.Close()
+		in, err := os.Open(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { in.Close() })
+		return OpenSymbols(in)
+	}
+
+	table, err := open(func(out *os.File) error {
+		return symbols.Write(out, TableInfo{Counter: uint32(BitHistory), Bits: 1})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table.Slots != 1<<10 || table.Counter != BitHistory || !table.Bits {
+		t.Fatalf("%d slots, counter %s and bits %t", table.Slots, table.Counter, table.Bits)
+	}
+	if slots := Window(table.Table, table.Slots, table.Slots-1, 1, 1); len(slots) != 2 {
+		t.Fatalf("the window at the end of the table is %d slots", len(slots))
+	}
+
+	// a table without table info was keyed without the markov rows
+	_, err = open(func(out *os.File) error {
+		_, err := out.Write(symbols.Slots)
+		return err
+	})
+	if err == nil {
+		t.Fatal("expected an error for a table without table info")
+	}
+	_, err = open(func(out *os.File) error {
+		_, err := out.Write(symbols.Slots)
+		if err != nil {
+			return err
+		}
+		return binary.Write(out, binary.LittleEndian, TableInfo{Version: TableVersion + 1})
+	})
+	if err == nil {
+		t.Fatal("expected an error for another table version")
+	}
+
+	_, err = open(func(out *os.File) error {
+		return symbols.Write(out, TableInfo{Counter: uint32(len(Counters))})
+	})
+	if err == nil {
+		t.Fatal("expected an error for an unknown counter")
+	}
+	_, err = open(func(out *os.File) error {
+		return symbols.Write(out, TableInfo{Bits: 2})
+	})
+	if err == nil {
+		t.Fatal("expected an error for an unknown bit mode")
+	}
+}
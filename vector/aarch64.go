@@ -2,8 +2,8 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-//go:build (!noasm && arm) || (!noasm && arm64)
-// +build !noasm,arm !noasm,arm64
+//go:build !noasm && arm64
+// +build !noasm,arm64
 
 package vector
 
@@ -11,7 +11,72 @@ import (
 	"unsafe"
 )
 
+// Dot computes the dot product of x and y
 func Dot(x, y []float32) (z float32) {
+	if len(x) < 4 {
+		// the kernel accumulator is only initialized by a full vector
+		return dot(x, y)
+	}
 	vdot(unsafe.Pointer(&x[0]), unsafe.Pointer(&y[0]), unsafe.Pointer(uintptr(len(x))), unsafe.Pointer(&z))
 	return z
 }
+
+// Max returns the largest element of x, which must not be empty
+func Max(x []float32) float32 {
+	if len(x) == 0 {
+		panic("slice is empty")
+	}
+	return maxNEON(x)
+}
+
+// Sum returns the sum of the elements of x
+func Sum(x []float32) float32 {
+	return sumNEON(x)
+}
+
+// Scale multiplies the elements of x by a
+func Scale(a float32, x []float32) {
+	scaleNEON(a, x)
+}
+
+// Add stores the element wise sum of x and y in z
+func Add(x, y, z []float32) {
+	if len(y) < len(x) || len(z) < len(x) {
+		panic("slice is too short")
+	}
+	addNEON(x, y, z)
+}
+
+// Sub stores the element wise difference of x and y in z
+func Sub(x, y, z []float32) {
+	if len(y) < len(x) || len(z) < len(x) {
+		panic("slice is too short")
+	}
+	subNEON(x, y, z)
+}
+
+// Mul stores the element wise product of x and y in z
+func Mul(x, y, z []float32) {
+	if len(y) < len(x) || len(z) < len(x) {
+		panic("slice is too short")
+	}
+	mulNEON(x, y, z)
+}
+
+// Axpy adds a times x to y
+func Axpy(a float32, x, y []float32) {
+	if len(y) < len(x) {
+		panic("slice is too short")
+	}
+	axpyNEON(a, x, y)
+}
+
+// Blend16 moves entry i of a cdf towards i, or i plus offset after entry s, by the
+// difference shifted right by rate
+func Blend16(model []uint16, s, offset, rate int) {
+	if len(model)+offset > Blend16Limit {
+		blend16(model, s, offset, rate)
+		return
+	}
+	blend16NEON(model, s, offset, rate)
+}
@@ -9,9 +9,76 @@ package vector
 
 import (
 	"unsafe"
+
+	"golang.org/x/sys/cpu"
 )
 
+// Dot computes the dot product of x and y
 func Dot(x, y []float32) (z float32) {
+	if len(x) < 8 {
+		// the kernel accumulator is only initialized by a full vector
+		return dot(x, y)
+	}
 	_mm256_dot(unsafe.Pointer(&x[0]), unsafe.Pointer(&y[0]), unsafe.Pointer(uintptr(len(x))), unsafe.Pointer(&z))
 	return z
 }
+
+// Max returns the largest element of x, which must not be empty
+func Max(x []float32) float32 {
+	if len(x) == 0 {
+		panic("slice is empty")
+	}
+	return maxAVX(x)
+}
+
+// Sum returns the sum of the elements of x
+func Sum(x []float32) float32 {
+	return sumAVX(x)
+}
+
+// Scale multiplies the elements of x by a
+func Scale(a float32, x []float32) {
+	scaleAVX(a, x)
+}
+
+// Add stores the element wise sum of x and y in z
+func Add(x, y, z []float32) {
+	if len(y) < len(x) || len(z) < len(x) {
+		panic("slice is too short")
+	}
+	addAVX(x, y, z)
+}
+
+// Sub stores the element wise difference of x and y in z
+func Sub(x, y, z []float32) {
+	if len(y) < len(x) || len(z) < len(x) {
+		panic("slice is too short")
+	}
+	subAVX(x, y, z)
+}
+
+// Mul stores the element wise product of x and y in z
+func Mul(x, y, z []float32) {
+	if len(y) < len(x) || len(z) < len(x) {
+		panic("slice is too short")
+	}
+	mulAVX(x, y, z)
+}
+
+// Axpy adds a times x to y
+func Axpy(a float32, x, y []float32) {
+	if len(y) < len(x) {
+		panic("slice is too short")
+	}
+	axpyAVX(a, x, y)
+}
+
+// Blend16 moves entry i of a cdf towards i, or i plus offset after entry s, by the
+// difference shifted right by rate
+func Blend16(model []uint16, s, offset, rate int) {
+	if !cpu.X86.HasAVX2 || len(model)+offset > Blend16Limit {
+		blend16(model, s, offset, rate)
+		return
+	}
+	blend16AVX2(model, s, offset, rate)
+}
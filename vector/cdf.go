@@ -0,0 +1,19 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vector
+
+// Blend16Limit is the bound on the entries and targets of a cdf for the Blend16 kernels,
+// which use signed 16 bit lanes
+const Blend16Limit = 1 << 15
+
+func blend16(model []uint16, s, offset, rate int) {
+	for i, v := range model {
+		a, target := int(v), i
+		if i > s {
+			target += offset
+		}
+		model[i] = uint16(a + ((target - a) >> rate))
+	}
+}
@@ -0,0 +1,73 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vector
+
+// Float is the constraint on vector element types
+type Float interface {
+	float32 | float64
+}
+
+// Kernels are the vector operations for one element type
+type Kernels[T Float] struct {
+	Dot        func(x, y []T) T
+	Max        func(x []T) T
+	Sum        func(x []T) T
+	Scale      func(a T, x []T)
+	Add        func(x, y, z []T)
+	Sub        func(x, y, z []T)
+	Mul        func(x, y, z []T)
+	Axpy       func(a T, x, y []T)
+	Softmax    func(x []T)
+	LogSoftmax func(x []T)
+	LogSumExp  func(x []T) T
+	Entropy    func(x []T) T
+}
+
+// Float32 are the float32 kernels, SIMD accelerated where available
+var Float32 = Kernels[float32]{
+	Dot:        Dot,
+	Max:        Max,
+	Sum:        Sum,
+	Scale:      Scale,
+	Add:        Add,
+	Sub:        Sub,
+	Mul:        Mul,
+	Axpy:       Axpy,
+	Softmax:    Softmax,
+	LogSoftmax: LogSoftmax,
+	LogSumExp:  LogSumExp,
+	Entropy:    Entropy,
+}
+
+// Float64 are the float64 kernels
+var Float64 = Kernels[float64]{
+	Dot:   dot[float64],
+	Max:   maximum[float64],
+	Sum:   sum[float64],
+	Scale: scale[float64],
+	Add:   add[float64],
+	Sub:   sub[float64],
+	Mul:   mul[float64],
+	Axpy:  axpy[float64],
+	Softmax: func(x []float64) {
+		softmax(x, maximum[float64], sum[float64], scale[float64])
+	},
+	LogSoftmax: func(x []float64) {
+		logSoftmax(x, maximum[float64])
+	},
+	LogSumExp: func(x []float64) float64 {
+		return logSumExp(x, maximum[float64])
+	},
+	Entropy: entropy[float64],
+}
+
+// For returns the kernels for the element type T
+func For[T Float]() *Kernels[T] {
+	var zero T
+	if _, ok := any(zero).(float32); ok {
+		return any(&Float32).(*Kernels[T])
+	}
+	return any(&Float64).(*Kernels[T])
+}
@@ -4,9 +4,60 @@
 
 package vector
 
-func dot(x, y []float32) (z float32) {
+func dot[T Float](x, y []T) (z T) {
 	for i := range x {
 		z += x[i] * y[i]
 	}
 	return z
 }
+
+func maximum[T Float](x []T) T {
+	m := x[0]
+	for _, v := range x[1:] {
+		if v > m {
+			m = v
+		}
+	}
+	return m
+}
+
+func sum[T Float](x []T) (s T) {
+	for _, v := range x {
+		s += v
+	}
+	return s
+}
+
+func scale[T Float](a T, x []T) {
+	for i := range x {
+		x[i] *= a
+	}
+}
+
+func add[T Float](x, y, z []T) {
+	y, z = y[:len(x)], z[:len(x)]
+	for i, v := range x {
+		z[i] = v + y[i]
+	}
+}
+
+func sub[T Float](x, y, z []T) {
+	y, z = y[:len(x)], z[:len(x)]
+	for i, v := range x {
+		z[i] = v - y[i]
+	}
+}
+
+func mul[T Float](x, y, z []T) {
+	y, z = y[:len(x)], z[:len(x)]
+	for i, v := range x {
+		z[i] = v * y[i]
+	}
+}
+
+func axpy[T Float](a T, x, y []T) {
+	y = y[:len(x)]
+	for i, v := range x {
+		y[i] += a * v
+	}
+}
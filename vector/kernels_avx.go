@@ -0,0 +1,32 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !noasm && amd64
+// +build !noasm,amd64
+
+package vector
+
+//go:noescape
+func maxAVX(x []float32) float32
+
+//go:noescape
+func sumAVX(x []float32) float32
+
+//go:noescape
+func scaleAVX(a float32, x []float32)
+
+//go:noescape
+func addAVX(x, y, z []float32)
+
+//go:noescape
+func subAVX(x, y, z []float32)
+
+//go:noescape
+func mulAVX(x, y, z []float32)
+
+//go:noescape
+func axpyAVX(a float32, x, y []float32)
+
+//go:noescape
+func blend16AVX2(model []uint16, s, offset, rate int)
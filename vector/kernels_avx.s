@@ -0,0 +1,332 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !noasm && amd64
+// +build !noasm,amd64
+
+#include "textflag.h"
+
+// func maxAVX(x []float32) float32
+TEXT ·maxAVX(SB), NOSPLIT, $0-28
+	MOVQ  x_base+0(FP), SI
+	MOVQ  x_len+8(FP), CX
+	MOVSS (SI), X0
+	CMPQ  CX, $8
+	JLT   maxfirst
+	VMOVUPS (SI), Y0
+	ADDQ  $32, SI
+	SUBQ  $8, CX
+
+maxloop:
+	CMPQ CX, $8
+	JLT  maxreduce
+	VMAXPS (SI), Y0, Y0
+	ADDQ $32, SI
+	SUBQ $8, CX
+	JMP  maxloop
+
+maxreduce:
+	VEXTRACTF128 $1, Y0, X1
+	VMAXPS       X1, X0, X0
+	VPERMILPS    $0x4e, X0, X1
+	VMAXPS       X1, X0, X0
+	VPERMILPS    $0xb1, X0, X1
+	VMAXPS       X1, X0, X0
+	VZEROUPPER
+	JMP          maxtail
+
+maxfirst:
+	ADDQ $4, SI
+	DECQ CX
+
+maxtail:
+	TESTQ CX, CX
+	JEQ   maxdone
+	MAXSS (SI), X0
+	ADDQ  $4, SI
+	DECQ  CX
+	JMP   maxtail
+
+maxdone:
+	MOVSS X0, ret+24(FP)
+	RET
+
+// func sumAVX(x []float32) float32
+TEXT ·sumAVX(SB), NOSPLIT, $0-28
+	MOVQ   x_base+0(FP), SI
+	MOVQ   x_len+8(FP), CX
+	VXORPS Y0, Y0, Y0
+
+sumloop:
+	CMPQ CX, $8
+	JLT  sumreduce
+	VADDPS (SI), Y0, Y0
+	ADDQ $32, SI
+	SUBQ $8, CX
+	JMP  sumloop
+
+sumreduce:
+	VEXTRACTF128 $1, Y0, X1
+	VADDPS       X1, X0, X0
+	VPERMILPS    $0x4e, X0, X1
+	VADDPS       X1, X0, X0
+	VPERMILPS    $0xb1, X0, X1
+	VADDPS       X1, X0, X0
+	VZEROUPPER
+
+sumtail:
+	TESTQ CX, CX
+	JEQ   sumdone
+	ADDSS (SI), X0
+	ADDQ  $4, SI
+	DECQ  CX
+	JMP   sumtail
+
+sumdone:
+	MOVSS X0, ret+24(FP)
+	RET
+
+// func scaleAVX(a float32, x []float32)
+TEXT ·scaleAVX(SB), NOSPLIT, $0-32
+	VBROADCASTSS a+0(FP), Y1
+	MOVQ         x_base+8(FP), SI
+	MOVQ         x_len+16(FP), CX
+
+scaleloop:
+	CMPQ CX, $8
+	JLT  scaletail
+	VMULPS  (SI), Y1, Y0
+	VMOVUPS Y0, (SI)
+	ADDQ $32, SI
+	SUBQ $8, CX
+	JMP  scaleloop
+
+scaletail:
+	VZEROUPPER
+
+scaletailloop:
+	TESTQ CX, CX
+	JEQ   scaledone
+	MOVSS (SI), X0
+	MULSS X1, X0
+	MOVSS X0, (SI)
+	ADDQ  $4, SI
+	DECQ  CX
+	JMP   scaletailloop
+
+scaledone:
+	RET
+
+// func addAVX(x, y, z []float32)
+TEXT ·addAVX(SB), NOSPLIT, $0-72
+	MOVQ x_base+0(FP), SI
+	MOVQ x_len+8(FP), CX
+	MOVQ y_base+24(FP), DI
+	MOVQ z_base+48(FP), DX
+
+addloop:
+	CMPQ CX, $8
+	JLT  addtail
+	VMOVUPS (SI), Y0
+	VADDPS  (DI), Y0, Y0
+	VMOVUPS Y0, (DX)
+	ADDQ $32, SI
+	ADDQ $32, DI
+	ADDQ $32, DX
+	SUBQ $8, CX
+	JMP  addloop
+
+addtail:
+	VZEROUPPER
+
+addtailloop:
+	TESTQ CX, CX
+	JEQ   adddone
+	MOVSS (SI), X0
+	ADDSS (DI), X0
+	MOVSS X0, (DX)
+	ADDQ  $4, SI
+	ADDQ  $4, DI
+	ADDQ  $4, DX
+	DECQ  CX
+	JMP   addtailloop
+
+adddone:
+	RET
+
+// func subAVX(x, y, z []float32)
+TEXT ·subAVX(SB), NOSPLIT, $0-72
+	MOVQ x_base+0(FP), SI
+	MOVQ x_len+8(FP), CX
+	MOVQ y_base+24(FP), DI
+	MOVQ z_base+48(FP), DX
+
+subloop:
+	CMPQ CX, $8
+	JLT  subtail
+	VMOVUPS (SI), Y0
+	VSUBPS  (DI), Y0, Y0
+	VMOVUPS Y0, (DX)
+	ADDQ $32, SI
+	ADDQ $32, DI
+	ADDQ $32, DX
+	SUBQ $8, CX
+	JMP  subloop
+
+subtail:
+	VZEROUPPER
+
+subtailloop:
+	TESTQ CX, CX
+	JEQ   subdone
+	MOVSS (SI), X0
+	SUBSS (DI), X0
+	MOVSS X0, (DX)
+	ADDQ  $4, SI
+	ADDQ  $4, DI
+	ADDQ  $4, DX
+	DECQ  CX
+	JMP   subtailloop
+
+subdone:
+	RET
+
+// func mulAVX(x, y, z []float32)
+TEXT ·mulAVX(SB), NOSPLIT, $0-72
+	MOVQ x_base+0(FP), SI
+	MOVQ x_len+8(FP), CX
+	MOVQ y_base+24(FP), DI
+	MOVQ z_base+48(FP), DX
+
+mulloop:
+	CMPQ CX, $8
+	JLT  multail
+	VMOVUPS (SI), Y0
+	VMULPS  (DI), Y0, Y0
+	VMOVUPS Y0, (DX)
+	ADDQ $32, SI
+	ADDQ $32, DI
+	ADDQ $32, DX
+	SUBQ $8, CX
+	JMP  mulloop
+
+multail:
+	VZEROUPPER
+
+multailloop:
+	TESTQ CX, CX
+	JEQ   muldone
+	MOVSS (SI), X0
+	MULSS (DI), X0
+	MOVSS X0, (DX)
+	ADDQ  $4, SI
+	ADDQ  $4, DI
+	ADDQ  $4, DX
+	DECQ  CX
+	JMP   multailloop
+
+muldone:
+	RET
+
+// func axpyAVX(a float32, x, y []float32)
+TEXT ·axpyAVX(SB), NOSPLIT, $0-56
+	VBROADCASTSS a+0(FP), Y1
+	MOVQ         x_base+8(FP), SI
+	MOVQ         x_len+16(FP), CX
+	MOVQ         y_base+32(FP), DI
+
+axpyloop:
+	CMPQ CX, $8
+	JLT  axpytail
+	VMULPS  (SI), Y1, Y0
+	VADDPS  (DI), Y0, Y0
+	VMOVUPS Y0, (DI)
+	ADDQ $32, SI
+	ADDQ $32, DI
+	SUBQ $8, CX
+	JMP  axpyloop
+
+axpytail:
+	VZEROUPPER
+
+axpytailloop:
+	TESTQ CX, CX
+	JEQ   axpydone
+	MOVSS (SI), X0
+	MULSS X1, X0
+	ADDSS (DI), X0
+	MOVSS X0, (DI)
+	ADDQ  $4, SI
+	ADDQ  $4, DI
+	DECQ  CX
+	JMP   axpytailloop
+
+axpydone:
+	RET
+
+DATA blendindex<>+0(SB)/8, $0x0003000200010000
+DATA blendindex<>+8(SB)/8, $0x0007000600050004
+DATA blendindex<>+16(SB)/8, $0x000b000a00090008
+DATA blendindex<>+24(SB)/8, $0x000f000e000d000c
+GLOBL blendindex<>(SB), RODATA|NOPTR, $32
+
+// func blend16AVX2(model []uint16, s, offset, rate int)
+TEXT ·blend16AVX2(SB), NOSPLIT, $0-48
+	MOVQ         model_base+0(FP), SI
+	MOVQ         model_len+8(FP), R8
+	MOVQ         s+24(FP), R9
+	MOVQ         offset+32(FP), R10
+	MOVQ         rate+40(FP), CX
+	XORQ         DX, DX
+	VMOVDQU      blendindex<>(SB), Y0
+	MOVQ         $16, AX
+	VMOVQ        AX, X1
+	VPBROADCASTW X1, Y1
+	VMOVQ        R9, X2
+	VPBROADCASTW X2, Y2
+	VMOVQ        R10, X3
+	VPBROADCASTW X3, Y3
+	VMOVQ        CX, X4
+
+blendloop:
+	CMPQ     R8, $16
+	JLT      blendreduce
+	VMOVDQU  (SI), Y5
+	VPCMPGTW Y2, Y0, Y6
+	VPAND    Y3, Y6, Y6
+	VPADDW   Y0, Y6, Y6
+	VPSUBW   Y5, Y6, Y6
+	VPSRAW   X4, Y6, Y6
+	VPADDW   Y6, Y5, Y5
+	VMOVDQU  Y5, (SI)
+	VPADDW   Y1, Y0, Y0
+	ADDQ     $32, SI
+	ADDQ     $16, DX
+	SUBQ     $16, R8
+	JMP      blendloop
+
+blendreduce:
+	VZEROUPPER
+
+blendtail:
+	TESTQ   R8, R8
+	JEQ     blenddone
+	MOVWQZX (SI), AX
+	MOVQ    DX, BX
+	CMPQ    DX, R9
+	JLE     blendtarget
+	ADDQ    R10, BX
+
+blendtarget:
+	SUBQ AX, BX
+	SARQ CX, BX
+	ADDQ BX, AX
+	MOVW AX, (SI)
+	ADDQ $2, SI
+	INCQ DX
+	DECQ R8
+	JMP  blendtail
+
+blenddone:
+	RET
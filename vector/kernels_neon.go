@@ -0,0 +1,32 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !noasm && arm64
+// +build !noasm,arm64
+
+package vector
+
+//go:noescape
+func maxNEON(x []float32) float32
+
+//go:noescape
+func sumNEON(x []float32) float32
+
+//go:noescape
+func scaleNEON(a float32, x []float32)
+
+//go:noescape
+func addNEON(x, y, z []float32)
+
+//go:noescape
+func subNEON(x, y, z []float32)
+
+//go:noescape
+func mulNEON(x, y, z []float32)
+
+//go:noescape
+func axpyNEON(a float32, x, y []float32)
+
+//go:noescape
+func blend16NEON(model []uint16, s, offset, rate int)
@@ -0,0 +1,275 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !noasm && arm64
+// +build !noasm,arm64
+
+#include "textflag.h"
+
+// func maxNEON(x []float32) float32
+TEXT ·maxNEON(SB), NOSPLIT, $0-28
+	MOVD  x_base+0(FP), R0
+	MOVD  x_len+8(FP), R1
+	FMOVS (R0), F0
+	CMP   $4, R1
+	BLT   maxfirst
+	VLD1.P 16(R0), [V0.S4]
+	SUB   $4, R1
+
+maxloop:
+	CMP  $4, R1
+	BLT  maxreduce
+	VLD1.P 16(R0), [V1.S4]
+	WORD $0x4e21f400 // fmax v0.4s, v0.4s, v1.4s
+	SUB  $4, R1
+	B    maxloop
+
+maxreduce:
+	WORD   $0x6e30f800 // fmaxv s0, v0.4s
+	B      maxtail
+
+maxfirst:
+	ADD $4, R0
+	SUB $1, R1
+
+maxtail:
+	CBZ   R1, maxdone
+	FMOVS.P 4(R0), F1
+	FMAXS F1, F0, F0
+	SUB   $1, R1
+	B     maxtail
+
+maxdone:
+	FMOVS F0, ret+24(FP)
+	RET
+
+// func sumNEON(x []float32) float32
+TEXT ·sumNEON(SB), NOSPLIT, $0-28
+	MOVD x_base+0(FP), R0
+	MOVD x_len+8(FP), R1
+	VEOR V0.B16, V0.B16, V0.B16
+
+sumloop:
+	CMP  $4, R1
+	BLT  sumreduce
+	VLD1.P 16(R0), [V1.S4]
+	WORD $0x4e21d400 // fadd v0.4s, v0.4s, v1.4s
+	SUB  $4, R1
+	B    sumloop
+
+sumreduce:
+	WORD $0x6e20d400 // faddp v0.4s, v0.4s, v0.4s
+	WORD $0x6e20d400 // faddp v0.4s, v0.4s, v0.4s
+
+sumtail:
+	CBZ   R1, sumdone
+	FMOVS.P 4(R0), F1
+	FADDS F1, F0, F0
+	SUB   $1, R1
+	B     sumtail
+
+sumdone:
+	FMOVS F0, ret+24(FP)
+	RET
+
+// func scaleNEON(a float32, x []float32)
+TEXT ·scaleNEON(SB), NOSPLIT, $0-32
+	FMOVS a+0(FP), F2
+	MOVD  x_base+8(FP), R0
+	MOVD  x_len+16(FP), R1
+	VDUP  V2.S[0], V3.S4
+
+scaleloop:
+	CMP  $4, R1
+	BLT  scaletail
+	VLD1 (R0), [V1.S4]
+	WORD $0x6e23dc21 // fmul v1.4s, v1.4s, v3.4s
+	VST1.P [V1.S4], 16(R0)
+	SUB  $4, R1
+	B    scaleloop
+
+scaletail:
+	CBZ   R1, scaledone
+	FMOVS (R0), F1
+	FMULS F2, F1, F1
+	FMOVS.P F1, 4(R0)
+	SUB   $1, R1
+	B     scaletail
+
+scaledone:
+	RET
+
+// func addNEON(x, y, z []float32)
+TEXT ·addNEON(SB), NOSPLIT, $0-72
+	MOVD x_base+0(FP), R0
+	MOVD x_len+8(FP), R1
+	MOVD y_base+24(FP), R2
+	MOVD z_base+48(FP), R3
+
+addloop:
+	CMP  $4, R1
+	BLT  addtail
+	VLD1.P 16(R0), [V0.S4]
+	VLD1.P 16(R2), [V1.S4]
+	WORD $0x4e21d400 // fadd v0.4s, v0.4s, v1.4s
+	VST1.P [V0.S4], 16(R3)
+	SUB  $4, R1
+	B    addloop
+
+addtail:
+	CBZ   R1, adddone
+	FMOVS.P 4(R0), F0
+	FMOVS.P 4(R2), F1
+	FADDS F1, F0, F0
+	FMOVS.P F0, 4(R3)
+	SUB   $1, R1
+	B     addtail
+
+adddone:
+	RET
+
+// func subNEON(x, y, z []float32)
+TEXT ·subNEON(SB), NOSPLIT, $0-72
+	MOVD x_base+0(FP), R0
+	MOVD x_len+8(FP), R1
+	MOVD y_base+24(FP), R2
+	MOVD z_base+48(FP), R3
+
+subloop:
+	CMP  $4, R1
+	BLT  subtail
+	VLD1.P 16(R0), [V0.S4]
+	VLD1.P 16(R2), [V1.S4]
+	WORD $0x4ea1d400 // fsub v0.4s, v0.4s, v1.4s
+	VST1.P [V0.S4], 16(R3)
+	SUB  $4, R1
+	B    subloop
+
+subtail:
+	CBZ   R1, subdone
+	FMOVS.P 4(R0), F0
+	FMOVS.P 4(R2), F1
+	FSUBS F1, F0, F0
+	FMOVS.P F0, 4(R3)
+	SUB   $1, R1
+	B     subtail
+
+subdone:
+	RET
+
+// func mulNEON(x, y, z []float32)
+TEXT ·mulNEON(SB), NOSPLIT, $0-72
+	MOVD x_base+0(FP), R0
+	MOVD x_len+8(FP), R1
+	MOVD y_base+24(FP), R2
+	MOVD z_base+48(FP), R3
+
+mulloop:
+	CMP  $4, R1
+	BLT  multail
+	VLD1.P 16(R0), [V0.S4]
+	VLD1.P 16(R2), [V1.S4]
+	WORD $0x6e21dc00 // fmul v0.4s, v0.4s, v1.4s
+	VST1.P [V0.S4], 16(R3)
+	SUB  $4, R1
+	B    mulloop
+
+multail:
+	CBZ   R1, muldone
+	FMOVS.P 4(R0), F0
+	FMOVS.P 4(R2), F1
+	FMULS F1, F0, F0
+	FMOVS.P F0, 4(R3)
+	SUB   $1, R1
+	B     multail
+
+muldone:
+	RET
+
+// func axpyNEON(a float32, x, y []float32)
+TEXT ·axpyNEON(SB), NOSPLIT, $0-56
+	FMOVS a+0(FP), F2
+	MOVD  x_base+8(FP), R0
+	MOVD  x_len+16(FP), R1
+	MOVD  y_base+32(FP), R2
+	VDUP  V2.S[0], V3.S4
+
+axpyloop:
+	CMP  $4, R1
+	BLT  axpytail
+	VLD1.P 16(R0), [V0.S4]
+	VLD1 (R2), [V1.S4]
+	WORD $0x4e23cc01 // fmla v1.4s, v0.4s, v3.4s
+	VST1.P [V1.S4], 16(R2)
+	SUB  $4, R1
+	B    axpyloop
+
+axpytail:
+	CBZ    R1, axpydone
+	FMOVS.P 4(R0), F0
+	FMOVS  (R2), F1
+	FMADDS F2, F1, F0, F1
+	FMOVS.P F1, 4(R2)
+	SUB    $1, R1
+	B      axpytail
+
+axpydone:
+	RET
+
+DATA blendindex<>+0(SB)/8, $0x0003000200010000
+DATA blendindex<>+8(SB)/8, $0x0007000600050004
+GLOBL blendindex<>(SB), RODATA|NOPTR, $16
+
+// func blend16NEON(model []uint16, s, offset, rate int)
+TEXT ·blend16NEON(SB), NOSPLIT, $0-48
+	MOVD model_base+0(FP), R0
+	MOVD model_len+8(FP), R1
+	MOVD s+24(FP), R3
+	MOVD offset+32(FP), R4
+	MOVD rate+40(FP), R5
+	MOVD $0, R8
+	MOVD $blendindex<>(SB), R6
+	VLD1 (R6), [V0.H8]
+	MOVD $8, R6
+	VDUP R6, V1.H8
+	VDUP R3, V2.H8
+	VDUP R4, V3.H8
+	NEG  R5, R6
+	VDUP R6, V4.H8
+
+blendloop:
+	CMP    $8, R1
+	BLT    blendtail
+	VLD1   (R0), [V5.H8]
+	WORD   $0x4e623406 // cmgt v6.8h, v0.8h, v2.8h
+	WORD   $0x4e231cc6 // and v6.16b, v6.16b, v3.16b
+	WORD   $0x4e6084c6 // add v6.8h, v6.8h, v0.8h
+	WORD   $0x6e6584c6 // sub v6.8h, v6.8h, v5.8h
+	WORD   $0x4e6444c6 // sshl v6.8h, v6.8h, v4.8h
+	WORD   $0x4e6684a5 // add v5.8h, v5.8h, v6.8h
+	VST1.P [V5.H8], 16(R0)
+	WORD   $0x4e618400 // add v0.8h, v0.8h, v1.8h
+	ADD    $8, R8
+	SUB    $8, R1
+	B      blendloop
+
+blendtail:
+	CBZ   R1, blenddone
+	MOVHU (R0), R7
+	MOVD  R8, R9
+	CMP   R3, R8
+	BLE   blendtarget
+	ADD   R4, R9
+
+blendtarget:
+	SUB    R7, R9
+	ASR    R5, R9
+	ADD    R9, R7
+	MOVH.P R7, 2(R0)
+	ADD    $1, R8
+	SUB    $1, R1
+	B      blendtail
+
+blenddone:
+	RET
@@ -0,0 +1,153 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vector
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestKernels(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, n := range []int{1, 3, 8, 13, 256, Size + 5} {
+		x := make([]float32, n)
+		for i := range x {
+			x[i] = float32(rng.NormFloat64())
+		}
+		if a, b := Max(x), maximum(x); a != b {
+			t.Fatalf("max is broken %f != %f", a, b)
+		}
+		if a, b := Sum(x), sum(x); math.Abs(float64(a-b)) > 1e-3*math.Max(1, math.Abs(float64(b))) {
+			t.Fatalf("sum is broken %f != %f", a, b)
+		}
+		y := make([]float32, n)
+		copy(y, x)
+		Scale(3, x)
+		scale(3, y)
+		for i := range x {
+			if x[i] != y[i] {
+				t.Fatalf("scale is broken %f != %f", x[i], y[i])
+			}
+		}
+
+		for i := range y {
+			y[i] = float32(rng.NormFloat64())
+		}
+		a, b := make([]float32, n), make([]float32, n)
+		for _, kernel := range []struct {
+			name       string
+			fast, slow func(x, y, z []float32)
+		}{
+			{"add", Add, add[float32]},
+			{"sub", Sub, sub[float32]},
+			{"mul", Mul, mul[float32]},
+		} {
+			kernel.fast(x, y, a)
+			kernel.slow(x, y, b)
+			for i := range a {
+				if a[i] != b[i] {
+					t.Fatalf("%s is broken %f != %f", kernel.name, a[i], b[i])
+				}
+			}
+		}
+		copy(a, y)
+		copy(b, y)
+		Axpy(.5, x, a)
+		axpy(.5, x, b)
+		for i := range a {
+			if math.Abs(float64(a[i]-b[i])) > 1e-6 {
+				t.Fatalf("axpy is broken %f != %f", a[i], b[i])
+			}
+		}
+	}
+}
+
+func BenchmarkAxpy(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	x := make([]float32, Size)
+	for i := range x {
+		x[i] = float32(rng.NormFloat64())
+	}
+	y := make([]float32, Size)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Axpy(1e-6, x, y)
+	}
+}
+
+func TestShortDot(t *testing.T) {
+	for n := 0; n < 40; n++ {
+		x := make([]float32, n+8)
+		for i := range x {
+			x[i] = float32(i + 1)
+		}
+		if a, b := Dot(x[:n], x[:n]), dot(x[:n], x[:n]); a != b {
+			t.Fatalf("dot product of length %d is broken %f != %f", n, a, b)
+		}
+	}
+}
+
+func TestBlend16(t *testing.T) {
+	const scale = 1 << 13
+	rng := rand.New(rand.NewSource(1))
+	for _, size := range []int{2, 7, 8, 9, 16, 17, 256, scale / 2} {
+		a, b := make([]uint16, size+1), make([]uint16, size+1)
+		for i := range a {
+			a[i] = uint16(i * scale / size)
+			b[i] = a[i]
+		}
+		offset := scale - size
+		for j := 0; j < 64; j++ {
+			s, rate := rng.Intn(size), 1+rng.Intn(13)
+			Blend16(a, s, offset, rate)
+			// the scalar cdf update that the kernels replace
+			for i := 1; i <= s; i++ {
+				v := int(b[i])
+				b[i] = uint16(v + ((i - v) >> rate))
+			}
+			for i := s + 1; i < size; i++ {
+				v := int(b[i])
+				b[i] = uint16(v + ((i + offset - v) >> rate))
+			}
+			for i := range a {
+				if a[i] != b[i] {
+					t.Fatalf("size %d entry %d: %d != %d", size, i, a[i], b[i])
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkBlend16(b *testing.B) {
+	model := make([]uint16, 257)
+	for i := range model {
+		model[i] = uint16(32 * i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Blend16(model, i&255, (1<<13)-256, 1+i&7)
+	}
+}
+
+func BenchmarkBlend16Scalar(b *testing.B) {
+	model := make([]uint16, 257)
+	for i := range model {
+		model[i] = uint16(32 * i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		blend16(model, i&255, (1<<13)-256, 1+i&7)
+	}
+}
+
+func TestEmptyMax(t *testing.T) {
+	defer func() {
+		if r := recover(); r != "slice is empty" {
+			t.Fatalf("recovered %v", r)
+		}
+	}()
+	Max(nil)
+}
@@ -2,11 +2,55 @@
 // Use of this source code is governed by a BSD-style
 // license that can be found in the LICENSE file.
 
-//go:build 386
-// +build 386
+//go:build noasm || !(amd64 || arm64)
+// +build noasm !amd64,!arm64
 
 package vector
 
 func Dot(x, y []float32) float32 {
 	return dot(x, y)
 }
+
+// Max returns the largest element of x, which must not be empty
+func Max(x []float32) float32 {
+	if len(x) == 0 {
+		panic("slice is empty")
+	}
+	return maximum(x)
+}
+
+// Sum returns the sum of the elements of x
+func Sum(x []float32) float32 {
+	return sum(x)
+}
+
+// Scale multiplies the elements of x by a
+func Scale(a float32, x []float32) {
+	scale(a, x)
+}
+
+// Add stores the element wise sum of x and y in z
+func Add(x, y, z []float32) {
+	add(x, y, z)
+}
+
+// Sub stores the element wise difference of x and y in z
+func Sub(x, y, z []float32) {
+	sub(x, y, z)
+}
+
+// Mul stores the element wise product of x and y in z
+func Mul(x, y, z []float32) {
+	mul(x, y, z)
+}
+
+// Axpy adds a times x to y
+func Axpy(a float32, x, y []float32) {
+	axpy(a, x, y)
+}
+
+// Blend16 moves entry i of a cdf towards i, or i plus offset after entry s, by the
+// difference shifted right by rate
+func Blend16(model []uint16, s, offset, rate int) {
+	blend16(model, s, offset, rate)
+}
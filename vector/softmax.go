@@ -0,0 +1,72 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vector
+
+import (
+	"math"
+)
+
+// Softmax computes the softmax of x in place, subtracting the max for stability
+func Softmax(x []float32) {
+	softmax(x, Max, Sum, Scale)
+}
+
+// LogSumExp computes log(sum(exp(x))) without overflow
+func LogSumExp(x []float32) float32 {
+	return logSumExp(x, Max)
+}
+
+// LogSoftmax computes the log of the softmax of x in place
+func LogSoftmax(x []float32) {
+	logSoftmax(x, Max)
+}
+
+// Entropy computes the entropy of the distribution x, zero probabilities contribute nothing
+func Entropy(x []float32) float32 {
+	return entropy(x)
+}
+
+func softmax[T Float](x []T, max, sum func(x []T) T, scale func(a T, x []T)) {
+	if len(x) == 0 {
+		return
+	}
+	m := max(x)
+	for i, v := range x {
+		x[i] = T(math.Exp(float64(v - m)))
+	}
+	scale(1/sum(x), x)
+}
+
+func logSumExp[T Float](x []T, max func(x []T) T) T {
+	if len(x) == 0 {
+		return T(math.Inf(-1))
+	}
+	m := max(x)
+	if math.IsInf(float64(m), 0) {
+		return m
+	}
+	s := 0.0
+	for _, v := range x {
+		s += math.Exp(float64(v - m))
+	}
+	return m + T(math.Log(s))
+}
+
+func logSoftmax[T Float](x []T, max func(x []T) T) {
+	lse := logSumExp(x, max)
+	for i, v := range x {
+		x[i] = v - lse
+	}
+}
+
+func entropy[T Float](x []T) T {
+	e := 0.0
+	for _, v := range x {
+		if v > 0 {
+			e -= float64(v) * math.Log(float64(v))
+		}
+	}
+	return T(e)
+}
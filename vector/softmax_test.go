@@ -0,0 +1,54 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package vector
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSoftmax(t *testing.T) {
+	x := []float32{-1000, -1001, -1002}
+	Softmax(x)
+	if s := x[0] + x[1] + x[2]; math.Abs(float64(s)-1) > 1e-6 {
+		t.Fatalf("%f != 1", s)
+	}
+	if x[0] <= x[1] || x[1] <= x[2] {
+		t.Fatalf("softmax is not monotonic %v", x)
+	}
+	if e := math.Exp(-1); math.Abs(float64(x[1]/x[0])-e) > 1e-6 {
+		t.Fatalf("%f != %f", x[1]/x[0], e)
+	}
+
+	y := []float32{1000, 1001, 1002}
+	if lse := LogSumExp(y); math.Abs(float64(lse)-(1002+math.Log(1+math.Exp(-1)+math.Exp(-2)))) > 1e-3 {
+		t.Fatalf("%f", lse)
+	}
+	LogSoftmax(y)
+	for i, v := range y {
+		if math.Abs(math.Exp(float64(v))-float64(x[2-i])) > 1e-4 {
+			t.Fatalf("%f != %f", math.Exp(float64(v)), x[2-i])
+		}
+	}
+}
+
+func TestEntropy(t *testing.T) {
+	if e := Entropy([]float32{1, 0, 0}); e != 0 {
+		t.Fatalf("%f != 0", e)
+	}
+	if e := Entropy([]float32{.5, .5, 0}); math.Abs(float64(e)-math.Ln2) > 1e-6 {
+		t.Fatalf("%f != %f", e, math.Ln2)
+	}
+}
+
+func BenchmarkSoftmax(b *testing.B) {
+	x := make([]float32, 256)
+	for i := 0; i < b.N; i++ {
+		for j := range x {
+			x[j] = float32(j)
+		}
+		Softmax(x)
+	}
+}
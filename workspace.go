@@ -0,0 +1,154 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+const (
+	// MixerRows is the number of rows in a mixer matrix
+	MixerRows = Size + Order + 1
+	// MaxTouched is the number of changed columns tracked per row before the row is recomputed
+	MaxTouched = 64
+)
+
+// Workspace holds the reusable buffers of a mixer matrix and its self attention,
+// the rows are kept up to date incrementally between calls to Mix
+type Workspace struct {
+	Input      Matrix[float32]
+	Transposed Matrix[float32]
+	Values     []float32
+	Output     []float32
+	Hot        []int
+	Markov     Markov
+	Dirty      [Size]bool
+	Sums       [Size]float32
+	Touched    [Size][]byte
+}
+
+// NewWorkspace makes a new workspace for a mixer matrix of zero markov rows
+func NewWorkspace() *Workspace {
+	w := &Workspace{
+		Input:      NewMatrix(InputSize, MixerRows, make([]float32, InputSize*MixerRows)...),
+		Transposed: NewMatrix(MixerRows, InputSize, make([]float32, InputSize*MixerRows)...),
+		Values:     make([]float32, MixerRows),
+		Output:     make([]float32, InputSize),
+		Hot:        make([]int, MixerRows),
+	}
+	for i := range w.Hot {
+		w.Hot[i] = -1
+	}
+	for i := range w.Markov {
+		w.Set(Size+i, int(w.Markov[i]), 1)
+		w.Hot[Size+i] = int(w.Markov[i])
+	}
+	for i := range w.Dirty {
+		w.Dirty[i] = true
+		w.Touched[i] = make([]byte, 0, MaxTouched)
+	}
+	return w
+}
+
+// Set sets column j of row i and row j of column i of the transpose
+func (w *Workspace) Set(i, j int, value float32) {
+	w.Input.Data[i*InputSize+j] = value
+	w.Transposed.Data[j*MixerRows+i] = value
+}
+
+// Invalidate marks all of the distribution rows as changed
+func (w *Workspace) Invalidate() {
+	for i := range w.Dirty {
+		w.InvalidateRow(i)
+	}
+}
+
+// InvalidateRow marks all of distribution row i as changed
+func (w *Workspace) InvalidateRow(i int) {
+	w.Dirty[i] = true
+	w.Touched[i] = w.Touched[i][:0]
+}
+
+// Touch marks column j of distribution row i as changed
+func (w *Workspace) Touch(i int, j byte) {
+	if w.Dirty[i] {
+		return
+	}
+	if len(w.Touched[i]) >= MaxTouched {
+		w.Dirty[i] = true
+		w.Touched[i] = w.Touched[i][:0]
+		return
+	}
+	w.Touched[i] = append(w.Touched[i], j)
+}
+
+// SetCDF sets distribution row i from a cdf if it has changed
+func (w *Workspace) SetCDF(i int, model []uint16) {
+	if !w.Dirty[i] {
+		return
+	}
+	last, sum := uint16(0), float32(0.0)
+	for _, v := range model[1:] {
+		sum += float32(v - last)
+		last = v
+	}
+	last = 0
+	for j, v := range model[1:] {
+		w.Set(i, j, float32(v-last)/sum)
+		last = v
+	}
+	w.Dirty[i] = false
+}
+
+// SetHistogram sets distribution row i from a histogram, only updating the touched
+// columns if the histogram total has not changed
+func (w *Workspace) SetHistogram(i int, histogram *[256]byte) {
+	setHistogram(w, i, histogram)
+}
+
+// setHistogram sets distribution row i from a histogram of any count type
+func setHistogram[T byte | uint32 | float32](w *Workspace, i int, histogram *[256]T) {
+	sum, count := float32(0.0), 0
+	w.Hot[i] = -1
+	for j, v := range histogram {
+		sum += float32(v)
+		if v != 0 {
+			w.Hot[i], count = j, count+1
+		}
+	}
+	if count != 1 {
+		w.Hot[i] = -1
+	}
+	if w.Dirty[i] || sum != w.Sums[i] {
+		for j, v := range histogram {
+			w.Set(i, j, float32(v)/sum)
+		}
+	} else {
+		for _, j := range w.Touched[i] {
+			w.Set(i, int(j), float32(histogram[j])/sum)
+		}
+	}
+	w.Sums[i] = sum
+	w.Dirty[i] = false
+	w.Touched[i] = w.Touched[i][:0]
+}
+
+// SetMarkov sets the one hot markov rows, only updating the rows that changed
+func (w *Workspace) SetMarkov(markov Markov) {
+	for i, v := range markov {
+		if old := w.Markov[i]; old != v {
+			w.Set(Size+i, int(old), 0)
+			w.Set(Size+i, int(v), 1)
+			w.Hot[Size+i] = int(v)
+		}
+	}
+	w.Markov = markov
+}
+
+// SelfAttention computes the self attention of the workspace matrix
+func (w *Workspace) SelfAttention() [InputSize]float32 {
+	return attention(w.Input, w.Input, w.Transposed, w.Hot, w.Hot, w.Values, w.Output)
+}
+
+// CrossSelfAttention computes the cross self attention of the workspace matrix and b
+func (w *Workspace) CrossSelfAttention(b *Workspace) [InputSize]float32 {
+	return attention(w.Input, b.Input, w.Transposed, w.Hot, b.Hot, w.Values, w.Output)
+}
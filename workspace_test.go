@@ -0,0 +1,269 @@
+// Copyright 2025 The v Authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/pointlander/v/vector"
+)
+
+// dense computes the self attention the way it was computed before the sparse fast path,
+// with dense dot products for the scores and the weighted sum over the transpose
+func dense[T Float](x Matrix[T]) (result [InputSize]T) {
+	kernels := vector.For[T]()
+	V := x.T()
+	values, output := make([]T, x.Rows), make([]T, InputSize)
+	for i := 0; i < x.Rows; i++ {
+		K := x.Data[i*x.Cols : (i+1)*x.Cols]
+		for j := 0; j < x.Rows; j++ {
+			Q := x.Data[j*x.Cols : (j+1)*x.Cols]
+			values[j] = kernels.Dot(K, Q)
+		}
+		kernels.Softmax(values)
+		for j := 0; j < V.Rows; j++ {
+			V := V.Data[j*V.Cols : (j+1)*V.Cols]
+			output[j] += kernels.Dot(values, V)
+		}
+	}
+	normalize(output)
+	copy(result[:], output)
+	return result
+}
+
+// reference builds the mixer matrix from scratch
+func reference(rows [][]float32, markov Markov) Matrix[float32] {
+	x := NewMatrix[float32](256, MixerRows)
+	for _, row := range rows {
+		x.Data = append(x.Data, row...)
+	}
+	for _, v := range markov {
+		d := make([]float32, 256)
+		d[v] = 1
+		x.Data = append(x.Data, d...)
+	}
+	return x
+}
+
+func filteredReference(f *Filtered) [InputSize]float32 {
+	rows := [][]float32{}
+	for i := range f.Filters {
+		model := f.Filters[i].GetModel()
+		last, sum := uint16(0), float32(0.0)
+		for _, v := range model[1:] {
+			sum += float32(v - last)
+			last = v
+		}
+		row := []float32{}
+		last = 0
+		for _, v := range model[1:] {
+			row = append(row, float32(v-last)/sum)
+			last = v
+		}
+		rows = append(rows, row)
+	}
+	return dense(reference(rows, f.Markov))
+}
+
+func mixerReference(m *Mixer) [InputSize]float32 {
+	w, rows := NewWorkspace(), [][]float32{}
+	for i := 0; i < Size; i++ {
+		row := w.Input.Data[i*InputSize : (i+1)*InputSize]
+		if i < len(m.Histograms) {
+			m.Histograms[i].Set(w, i)
+		}
+		rows = append(rows, row)
+	}
+	return dense(reference(rows, m.Markov))
+}
+
+func TestWorkspace(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	f, m := NewFiltered(), NewMixer()
+	f.Add(0)
+	m.Add(0)
+	for i := 0; i < 1024; i++ {
+		s := byte(rng.Intn(16))
+		f.Add(s)
+		m.Add(s)
+		if i%3 == 0 {
+			continue
+		}
+		if a, b := f.Mix(), filteredReference(f); a != b {
+			t.Fatalf("filtered mix %d differs from the reference", i)
+		}
+		if a, b := m.Mix(), mixerReference(m); a != b {
+			t.Fatalf("mixer mix %d differs from the reference", i)
+		}
+	}
+}
+
+func TestHot(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 32; i++ {
+		x := NewMatrix[float32](InputSize, MixerRows)
+		for j := 0; j < MixerRows; j++ {
+			row := make([]float32, InputSize)
+			switch rng.Intn(3) {
+			case 0:
+				row[rng.Intn(4)] = float32(rng.Float64())
+			case 1:
+				for k := range row {
+					row[k] = float32(rng.Float64())
+				}
+			}
+			x.Data = append(x.Data, row...)
+		}
+		if a, b := SelfAttention(x), dense(x); a != b {
+			t.Fatal("sparse self attention differs from dense self attention")
+		}
+		if a, b := CrossSelfAttention(x, x), dense(x); a != b {
+			t.Fatal("sparse cross self attention differs from dense self attention")
+		}
+	}
+}
+
+func TestDecayingMixer(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	m := NewMixer(NewDecay(1), NewDecay(16), NewDecay(1024), NewRing(1), NewRing(300), NewRing(4096))
+	n := NewMixer(NewRing(1), NewRing(2), NewRing(4), NewRing(8), NewRing(16), NewRing(32), NewRing(64), NewRing(128))
+	r := NewMixer()
+	for i := 0; i < 1<<14; i++ {
+		s := byte(rng.Intn(16))
+		m.Add(s)
+		n.Add(s)
+		r.Add(s)
+		if i%97 != 0 {
+			continue
+		}
+		if a, b := m.Mix(), mixerReference(m); a != b {
+			t.Fatalf("mix %d differs from the reference", i)
+		}
+		if a, b := n.Mix(), r.Mix(); a != b {
+			t.Fatalf("ring mix %d differs from the histogram mix", i)
+		}
+	}
+	// the counts survive the rescaling every 64 half lives
+	d, expected := NewDecay(16), Distribution{}
+	for i := 0; i < 5000; i++ {
+		s := rng.Intn(256)
+		d.Add(byte(s))
+		for j := range expected {
+			expected[j] *= math.Exp2(-1.0 / 16)
+		}
+		expected[s]++
+	}
+	sum := 0.0
+	for _, v := range expected {
+		sum += v
+	}
+	for i, v := range d.Distribution() {
+		if math.Abs(v-expected[i]/sum) > 1e-4 {
+			t.Fatalf("symbol %d has probability %f, expected %f", i, v, expected[i]/sum)
+		}
+	}
+	// the half life of one halves the old counts on every symbol
+	d = NewDecay(1)
+	d.Add(1)
+	d.Add(2)
+	if p := d.Distribution(); math.Abs(p[2]-2*p[1]) > 1e-6 {
+		t.Fatalf("%f is not twice %f", p[2], p[1])
+	}
+}
+
+func TestMixAllocations(t *testing.T) {
+	f, m := NewFiltered(), NewMixer()
+	cf, cm := NewCrossFiltered(), NewCrossMixer()
+	d := NewMixer(NewDecay(8), NewRing(1000))
+	s := byte(0)
+	allocations := testing.AllocsPerRun(128, func() {
+		f.Add(s)
+		f.Mix()
+		m.Add(s)
+		m.Mix()
+		d.Add(s)
+		d.Mix()
+		cf.Add(s, s+1)
+		cf.Mix()
+		cm.Add(s, s+1)
+		cm.Mix()
+		s++
+	})
+	if allocations != 0 {
+		t.Fatalf("mix allocates %f times", allocations)
+	}
+}
+
+func BenchmarkFilteredMix(b *testing.B) {
+	f := NewFiltered()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f.Add(byte(i))
+		f.Mix()
+	}
+}
+
+func BenchmarkFilteredMixReference(b *testing.B) {
+	f := NewFiltered()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f.Add(byte(i))
+		filteredReference(f)
+	}
+}
+
+func BenchmarkMixerMix(b *testing.B) {
+	m := NewMixer()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Add(byte(i))
+		m.Mix()
+	}
+}
+
+func BenchmarkMixerMixReference(b *testing.B) {
+	m := NewMixer()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Add(byte(i))
+		mixerReference(m)
+	}
+}
+
+// benchmarkAttention times the self attention of the same workspace with the one hot rows
+// found by Hot or with every row treated as dense
+func benchmarkAttention(b *testing.B, sparse bool) {
+	f := NewFiltered()
+	for _, v := range []byte("the quick brown fox jumps over the lazy dog") {
+		f.Add(v)
+	}
+	w := f.workspace()
+	hot := w.Hot
+	if !sparse {
+		hot = make([]int, len(w.Hot))
+		for i := range hot {
+			hot[i] = -1
+		}
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		attention(w.Input, w.Input, w.Transposed, hot, hot, w.Values, w.Output)
+	}
+}
+
+func BenchmarkAttentionSparse(b *testing.B) {
+	benchmarkAttention(b, true)
+}
+
+func BenchmarkAttentionDense(b *testing.B) {
+	benchmarkAttention(b, false)
+}